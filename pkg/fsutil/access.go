@@ -0,0 +1,88 @@
+package fsutil
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// IsReadable returns true if the current process can open the file or read
+// the directory at path.
+// The result is only advisory: permissions may change between this check
+// and the actual use of path, so callers must still handle errors.
+func IsReadable(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	// opening fifos and devices might block or have side effects
+	if !fi.Mode().IsRegular() && !fi.IsDir() {
+		return false
+	}
+
+	fh, err := os.Open(path)
+	if err != nil {
+		debug.Log("%s is not readable: %s", path, err)
+
+		return false
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	// an empty directory returns io.EOF
+	if fi.IsDir() {
+		if _, err := fh.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+			debug.Log("%s is not readable: %s", path, err)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsWritable returns true if the current process can write to the file or
+// create files in the directory at path. Files are opened in append mode
+// and never truncated or created. For directories a temporary file is
+// created and removed again.
+// Like IsReadable the result is only advisory.
+func IsWritable(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	if fi.IsDir() {
+		fh, err := createTemp(path, ".gopass-probe-", 0o600)
+		if err != nil {
+			debug.Log("%s is not writable: %s", path, err)
+
+			return false
+		}
+
+		_ = fh.Close()
+		_ = os.Remove(fh.Name())
+
+		return true
+	}
+
+	if !fi.Mode().IsRegular() {
+		return false
+	}
+
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		debug.Log("%s is not writable: %s", path, err)
+
+		return false
+	}
+
+	_ = fh.Close()
+
+	return true
+}
@@ -0,0 +1,64 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsReadableWritable(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "foo")
+	require.NoError(t, os.WriteFile(fn, []byte("foo"), 0o600))
+
+	assert.True(t, IsReadable(fn))
+	assert.True(t, IsWritable(fn))
+	assert.True(t, IsReadable(tempdir))
+	assert.True(t, IsWritable(tempdir))
+
+	// the probe must not leave anything behind or modify the file
+	entries, err := os.ReadDir(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf))
+
+	assert.False(t, IsReadable(filepath.Join(tempdir, "non-existing")))
+	assert.False(t, IsWritable(filepath.Join(tempdir, "non-existing")))
+
+	// root can access everything and Windows doesn't support these modes
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		return
+	}
+
+	require.NoError(t, os.Chmod(fn, 0o200))
+	assert.False(t, IsReadable(fn))
+	assert.True(t, IsWritable(fn))
+
+	require.NoError(t, os.Chmod(fn, 0o400))
+	assert.True(t, IsReadable(fn))
+	assert.False(t, IsWritable(fn))
+
+	dir := filepath.Join(tempdir, "dir")
+	require.NoError(t, os.Mkdir(dir, 0o500))
+	assert.True(t, IsReadable(dir))
+	assert.False(t, IsWritable(dir))
+
+	require.NoError(t, os.Chmod(dir, 0o300))
+	assert.False(t, IsReadable(dir))
+	assert.True(t, IsWritable(dir))
+}
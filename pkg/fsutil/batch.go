@@ -0,0 +1,140 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// BatchWriter writes many files below a directory with the same guarantees
+// as WriteFileAtomic, but faster. Write does not sync anything. Commit
+// syncs the content of all pending files in one pass, renames them into
+// place and then syncs each affected directory only once. Until Commit
+// returns a crash may leave any of the files with their old or their new
+// content, afterwards all of them are durable. A BatchWriter is not safe
+// for concurrent use.
+type BatchWriter struct {
+	dir     string
+	pending []batchEntry
+}
+
+type batchEntry struct {
+	fh   *os.File
+	tmp  string
+	path string
+}
+
+// NewBatchWriter returns a BatchWriter for files below dir.
+func NewBatchWriter(dir string) *BatchWriter {
+	return &BatchWriter{dir: dir}
+}
+
+// Write writes data to a temporary file next to name which is relative to
+// the directory of the BatchWriter. The file is created with mode right
+// away. It only becomes visible at name after Commit. The parent directory
+// must exist already. The temporary file is kept open until Commit or Abort
+// since it might not be writable, and thus not syncable, once it's closed.
+func (b *BatchWriter) Write(name string, data []byte, mode os.FileMode) error {
+	path, err := ConfinePath(b.dir, name)
+	if err != nil {
+		return err
+	}
+
+	fh, err := createTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-", mode)
+	if err != nil {
+		return err
+	}
+
+	tmp := fh.Name()
+
+	if _, err := fh.Write(data); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to write %q: %w", tmp, err)
+	}
+
+	b.pending = append(b.pending, batchEntry{fh: fh, tmp: tmp, path: path})
+
+	return nil
+}
+
+// Commit syncs and closes all pending files, renames them into place, in the
+// order they were written, and syncs their directories. If syncing fails no
+// file is renamed and all of them are removed. If a rename fails the remaining
+// temporary files are removed, files renamed before stay in place. The
+// BatchWriter can be reused afterwards.
+func (b *BatchWriter) Commit() error {
+	pending := b.pending
+	b.pending = nil
+
+	if err := syncBatch(pending); err != nil {
+		removeBatch(pending)
+
+		return err
+	}
+
+	dirs := map[string]bool{}
+
+	for i, e := range pending {
+		if err := os.Rename(e.tmp, e.path); err != nil {
+			removeBatch(pending[i:])
+
+			return fmt.Errorf("failed to rename %q to %q: %w", e.tmp, e.path, err)
+		}
+
+		dirs[filepath.Dir(e.path)] = true
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+
+	sort.Strings(sorted)
+
+	for _, dir := range sorted {
+		if err := SyncDir(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Abort removes all pending files without renaming them.
+func (b *BatchWriter) Abort() {
+	for _, e := range b.pending {
+		_ = e.fh.Close()
+	}
+
+	removeBatch(b.pending)
+	b.pending = nil
+}
+
+// syncBatch syncs and closes all entries in one pass. If one of them fails
+// the others are still synced and all errors are returned.
+func syncBatch(entries []batchEntry) error {
+	var result error
+
+	for _, e := range entries {
+		if err := e.fh.Sync(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("failed to sync %q: %w", e.tmp, err))
+		}
+
+		if err := e.fh.Close(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("failed to close %q: %w", e.tmp, err))
+		}
+	}
+
+	return result
+}
+
+func removeBatch(entries []batchEntry) {
+	for _, e := range entries {
+		_ = os.Remove(e.tmp)
+	}
+}
@@ -0,0 +1,130 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchWriter(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	require.NoError(t, os.Mkdir(filepath.Join(tempdir, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "old.gpg"), []byte("old"), 0o600))
+
+	want := map[string]string{
+		"foo.gpg":     "foo",
+		"old.gpg":     "new",
+		"sub/bar.gpg": "bar",
+	}
+	modes := map[string]os.FileMode{
+		"foo.gpg":     0o600,
+		"old.gpg":     0o640,
+		"sub/bar.gpg": 0o644,
+	}
+
+	bw := NewBatchWriter(tempdir)
+	for _, name := range []string{"foo.gpg", "old.gpg", "sub/bar.gpg"} {
+		require.NoError(t, bw.Write(name, []byte(want[name]), modes[name]))
+	}
+
+	// nothing is visible before the commit
+	assert.Equal(t, false, IsFile(filepath.Join(tempdir, "foo.gpg")))
+	buf, err := os.ReadFile(filepath.Join(tempdir, "old.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(buf))
+
+	require.NoError(t, bw.Commit())
+
+	for name, content := range want {
+		fn := filepath.Join(tempdir, filepath.FromSlash(name))
+
+		buf, err := os.ReadFile(fn)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(buf), name)
+
+		if runtime.GOOS == "windows" {
+			continue
+		}
+
+		fi, err := os.Stat(fn)
+		require.NoError(t, err)
+		assert.Equal(t, modes[name], fi.Mode().Perm(), name)
+	}
+
+	// no temp files are left behind
+	files, err := ListFiles(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	// abort discards everything
+	require.NoError(t, bw.Write("baz.gpg", []byte("baz"), 0o600))
+	bw.Abort()
+	require.NoError(t, bw.Commit())
+	files, err = ListFiles(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	assert.Error(t, bw.Write("../escape.gpg", []byte("foo"), 0o600))
+	assert.Error(t, bw.Write("non-existing/foo.gpg", []byte("foo"), 0o600))
+}
+
+func BenchmarkWriteFileAtomic100(b *testing.B) {
+	data := []byte("secret")
+
+	benchmarkBatch(b, func(dir string) error {
+		for i := 0; i < 100; i++ {
+			if err := WriteFileAtomic(filepath.Join(dir, fmt.Sprintf("%d.gpg", i)), data, 0o600); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func BenchmarkBatchWriter100(b *testing.B) {
+	data := []byte("secret")
+
+	benchmarkBatch(b, func(dir string) error {
+		bw := NewBatchWriter(dir)
+		for i := 0; i < 100; i++ {
+			if err := bw.Write(fmt.Sprintf("%d.gpg", i), data, 0o600); err != nil {
+				return err
+			}
+		}
+
+		return bw.Commit()
+	})
+}
+
+func benchmarkBatch(b *testing.B, write func(dir string) error) {
+	b.Helper()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(b, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := write(tempdir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package fsutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+)
+
+// Checksum streams the content of the file through h and returns the hex
+// encoded digest.
+func Checksum(path string, h hash.Hash) (string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	if _, err := io.Copy(h, fh); err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SHA256Sum returns the hex encoded SHA-256 digest of the file.
+func SHA256Sum(path string) (string, error) {
+	return Checksum(path, sha256.New())
+}
@@ -0,0 +1,36 @@
+package fsutil
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "foo")
+	require.NoError(t, os.WriteFile(fn, []byte("foobar"), 0o600))
+
+	sum, err := SHA256Sum(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "c3ab8ff13720e8ad9047dd39466b3c8974e592c2fa383d4a3960714caef0c4f2", sum)
+
+	sum, err = Checksum(fn, sha1.New()) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, "8843d7f92416211de9ebb963ff4ce28125932878", sum)
+
+	_, err = SHA256Sum(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
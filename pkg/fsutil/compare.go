@@ -0,0 +1,194 @@
+package fsutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// compareBlockSize is the size of the chunks compared by FilesEqual.
+const compareBlockSize = 64 * 1024
+
+// FilesEqual returns true if both files have exactly the same content.
+// Files of different size are never read.
+func FilesEqual(a, b string) (bool, error) {
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %q: %w", a, err)
+	}
+
+	defer func() {
+		_ = fa.Close()
+	}()
+
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %q: %w", b, err)
+	}
+
+	defer func() {
+		_ = fb.Close()
+	}()
+
+	sa, err := fa.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %q: %w", a, err)
+	}
+
+	sb, err := fb.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %q: %w", b, err)
+	}
+
+	if sa.Size() != sb.Size() {
+		return false, nil
+	}
+
+	return readersEqual(fa, fb)
+}
+
+// readersEqual compares both readers chunk by chunk until they both end.
+func readersEqual(a, b io.Reader) (bool, error) {
+	bufA := make([]byte, compareBlockSize)
+	bufB := make([]byte, compareBlockSize)
+
+	for {
+		na, errA := io.ReadFull(a, bufA)
+		if errA != nil && !isShortRead(errA) {
+			return false, fmt.Errorf("failed to read: %w", errA)
+		}
+
+		nb, errB := io.ReadFull(b, bufB)
+		if errB != nil && !isShortRead(errB) {
+			return false, fmt.Errorf("failed to read: %w", errB)
+		}
+
+		if !bytes.Equal(bufA[:na], bufB[:nb]) {
+			return false, nil
+		}
+
+		// a short read means EOF was reached
+		if errA != nil || errB != nil {
+			return errA != nil && errB != nil, nil
+		}
+	}
+}
+
+func isShortRead(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// TreeEqual compares the directory trees a and b and returns true if both
+// contain the same entries with the same types, permissions and content.
+// Symlinks are compared by their targets and never followed. The slash
+// separated paths of all entries that differ or only exist on one side are
+// returned in lexical order. .git and entries matched by the DefaultIgnorer
+// are ignored, the permissions of a and b themselves are not compared.
+func TreeEqual(a, b string) (bool, []string, error) {
+	return treeEqual(a, b, WalkOpts{SkipGit: true})
+}
+
+// treeEqual implements TreeEqual, opts control which entries are compared.
+func treeEqual(a, b string, opts WalkOpts) (bool, []string, error) {
+	ea, err := treeEntries(a, opts)
+	if err != nil {
+		return false, nil, err
+	}
+
+	eb, err := treeEntries(b, opts)
+	if err != nil {
+		return false, nil, err
+	}
+
+	names := make([]string, 0, len(ea))
+	for name := range ea {
+		names = append(names, name)
+	}
+
+	for name := range eb {
+		if _, found := ea[name]; !found {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	diffs := []string{}
+
+	for _, name := range names {
+		ma, okA := ea[name]
+		mb, okB := eb[name]
+
+		if !okA || !okB || ma != mb {
+			diffs = append(diffs, name)
+
+			continue
+		}
+
+		eq, err := entriesEqual(filepath.Join(a, FromSlash(name)), filepath.Join(b, FromSlash(name)), ma)
+		if err != nil {
+			return false, nil, err
+		}
+
+		if !eq {
+			diffs = append(diffs, name)
+		}
+	}
+
+	return len(diffs) == 0, diffs, nil
+}
+
+// treeEntries returns the modes of all entries below root by their slash
+// separated relative path.
+func treeEntries(root string, opts WalkOpts) (map[string]os.FileMode, error) {
+	entries := map[string]os.FileMode{}
+
+	err := Walk(root, opts, func(path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path of %q: %w", path, err)
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %q: %w", path, err)
+		}
+
+		entries[ToSlash(rel)] = fi.Mode()
+
+		return nil
+	})
+
+	return entries, err
+}
+
+// entriesEqual compares the content of two entries sharing the same mode.
+func entriesEqual(pa, pb string, mode os.FileMode) (bool, error) {
+	switch {
+	case mode.IsRegular():
+		return FilesEqual(pa, pb)
+	case mode&os.ModeSymlink != 0:
+		ta, err := os.Readlink(pa)
+		if err != nil {
+			return false, fmt.Errorf("failed to read symlink %q: %w", pa, err)
+		}
+
+		tb, err := os.Readlink(pb)
+		if err != nil {
+			return false, fmt.Errorf("failed to read symlink %q: %w", pb, err)
+		}
+
+		return ta == tb, nil
+	default:
+		return true, nil
+	}
+}
@@ -0,0 +1,131 @@
+package fsutil
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesEqual(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	content := bytes.Repeat([]byte("0123456789"), compareBlockSize/5)
+	other := append([]byte{}, content...)
+	other[len(other)-1] = 'x'
+
+	a := filepath.Join(tempdir, "a")
+	b := filepath.Join(tempdir, "b")
+	c := filepath.Join(tempdir, "c")
+	d := filepath.Join(tempdir, "d")
+	require.NoError(t, os.WriteFile(a, content, 0o600))
+	require.NoError(t, os.WriteFile(b, content, 0o600))
+	require.NoError(t, os.WriteFile(c, other, 0o600))
+	require.NoError(t, os.WriteFile(d, content[:100], 0o600))
+
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{a, a, true},
+		{a, b, true},
+		{a, c, false},
+		{a, d, false},
+	} {
+		eq, err := FilesEqual(tc.a, tc.b)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, eq, "%s == %s", tc.a, tc.b)
+	}
+
+	_, err = FilesEqual(a, filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+	_, err = FilesEqual(filepath.Join(tempdir, "non-existing"), a)
+	assert.Error(t, err)
+}
+
+func TestReadersEqual(t *testing.T) {
+	t.Parallel()
+
+	eq, err := readersEqual(strings.NewReader("foo"), strings.NewReader("foobar"))
+	require.NoError(t, err)
+	assert.False(t, eq)
+
+	eq, err = readersEqual(strings.NewReader(""), strings.NewReader(""))
+	require.NoError(t, err)
+	assert.True(t, eq)
+}
+
+func TestTreeEqual(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	a := filepath.Join(tempdir, "a")
+	b := filepath.Join(tempdir, "b")
+
+	for _, root := range []string{a, b} {
+		for _, fn := range []string{".gpg-id", "foo.gpg", "sub/bar.gpg", "sub/dir/baz.gpg"} {
+			fp := filepath.Join(root, filepath.FromSlash(fn))
+			require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0o700))
+			require.NoError(t, os.WriteFile(fp, []byte(fn), 0o600))
+		}
+	}
+
+	// .git is ignored
+	require.NoError(t, os.MkdirAll(filepath.Join(a, ".git"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(a, ".git", "HEAD"), []byte("ref"), 0o600))
+
+	eq, diffs, err := TreeEqual(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, true, eq)
+	assert.Equal(t, []string{}, diffs)
+
+	// same length, one byte differs
+	require.NoError(t, os.WriteFile(filepath.Join(b, "sub", "bar.gpg"), []byte("sub/bar.gpx"), 0o600))
+
+	eq, diffs, err = TreeEqual(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, false, eq)
+	assert.Equal(t, []string{"sub/bar.gpg"}, diffs)
+
+	// missing and extra entries
+	require.NoError(t, os.Remove(filepath.Join(b, "foo.gpg")))
+	require.NoError(t, os.MkdirAll(filepath.Join(b, "extra"), 0o700))
+
+	eq, diffs, err = TreeEqual(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, false, eq)
+	assert.Equal(t, []string{"extra", "foo.gpg", "sub/bar.gpg"}, diffs)
+
+	_, _, err = TreeEqual(a, filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	require.NoError(t, os.Chmod(filepath.Join(b, "sub", "dir", "baz.gpg"), 0o644))
+	require.NoError(t, os.Symlink("foo.gpg", filepath.Join(a, "link")))
+	require.NoError(t, os.Symlink("bar.gpg", filepath.Join(b, "link")))
+
+	_, diffs, err = TreeEqual(a, b)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"extra", "foo.gpg", "link", "sub/bar.gpg", "sub/dir/baz.gpg"}, diffs)
+}
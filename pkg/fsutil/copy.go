@@ -0,0 +1,331 @@
+package fsutil
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// CopyFile copies the content of the regular file src to dst and preserves
+// the permissions of src. It refuses to overwrite an existing dst.
+func CopyFile(src, dst string) error {
+	fi, err := os.Stat(fixLongPath(src))
+	if err != nil {
+		return fmt.Errorf("failed to stat %q: %w", src, err)
+	}
+
+	return copyFile(src, dst, fi.Mode().Perm(), false, nil)
+}
+
+// CopyFileMode is like CopyFile but creates dst with the given mode.
+func CopyFileMode(src, dst string, mode os.FileMode) error {
+	return copyFile(src, dst, mode, false, nil)
+}
+
+// CopyFileForce is like CopyFile but replaces dst if it already exists. The
+// content is copied to a temporary file first, so dst is left alone if the
+// copy fails.
+func CopyFileForce(src, dst string) error {
+	fi, err := os.Stat(fixLongPath(src))
+	if err != nil {
+		return fmt.Errorf("failed to stat %q: %w", src, err)
+	}
+
+	return copyFile(src, dst, fi.Mode().Perm(), true, nil)
+}
+
+// CopyFileHash is like CopyFile but also streams the content through h and
+// returns the hex encoded digest, so the file only has to be read once.
+func CopyFileHash(src, dst string, h hash.Hash) (string, error) {
+	fi, err := os.Stat(fixLongPath(src))
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %q: %w", src, err)
+	}
+
+	if err := copyFile(src, dst, fi.Mode().Perm(), false, h); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFile copies src to dst, creating it with mode. If h is not nil the
+// content is written to it as well.
+func copyFile(src, dst string, mode os.FileMode, force bool, h hash.Hash) error {
+	src, dst = fixLongPath(src), fixLongPath(dst)
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", src, err)
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %q: %w", src, err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %q", src)
+	}
+
+	// truncating dst would destroy src otherwise
+	if dfi, err := os.Stat(dst); err == nil && os.SameFile(fi, dfi) {
+		return fmt.Errorf("can not copy %q to %q: %w", src, dst, ErrSameFile)
+	}
+
+	if force {
+		return copyReplace(in, dst, mode, h)
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create %q: %w", dst, err)
+	}
+
+	if err := copyContent(out, in, mode, h); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+
+		return fmt.Errorf("failed to copy %q to %q: %w", src, dst, err)
+	}
+
+	if err := out.Close(); err != nil {
+		_ = os.Remove(dst)
+
+		return fmt.Errorf("failed to close %q: %w", dst, err)
+	}
+
+	return nil
+}
+
+// copyReplace copies in to a temporary file next to dst and renames it over
+// dst afterwards, so an existing dst is left alone if the copy fails.
+func copyReplace(in *os.File, dst string, mode os.FileMode, h hash.Hash) error {
+	out, err := createTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-", mode)
+	if err != nil {
+		return err
+	}
+
+	tmp := out.Name()
+
+	if err := copyContent(out, in, mode, h); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to copy %q to %q: %w", in.Name(), tmp, err)
+	}
+
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to close %q: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to rename %q to %q: %w", tmp, dst, err)
+	}
+
+	return nil
+}
+
+// copyContent copies all data from in to out and syncs it to disk. The mode
+// is applied before anything is written, so neither the umask nor the
+// permissions of an existing file can expose the content. If h is not nil
+// the data is written to it as well.
+func copyContent(out, in *os.File, mode os.FileMode, h hash.Hash) error {
+	if err := out.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set mode: %w", err)
+	}
+
+	if h != nil {
+		if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+			return fmt.Errorf("failed to write: %w", err)
+		}
+	} else if err := copyData(out, in); err != nil {
+		return err
+	}
+
+	if err := out.Sync(); err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+
+	return nil
+}
+
+// copyBuffered copies all data from in to out through a userspace buffer.
+func copyBuffered(out, in *os.File) error {
+	// hide ReadFrom and WriteTo, otherwise io.Copy might use
+	// copy_file_range or sendfile on its own.
+	if _, err := io.Copy(struct{ io.Writer }{out}, struct{ io.Reader }{in}); err != nil {
+		return fmt.Errorf("failed to write: %w", err)
+	}
+
+	return nil
+}
+
+// CopyDirOpts controls the behaviour of CopyDirWithOpts.
+type CopyDirOpts struct {
+	// FollowSymlinks copies the targets of symlinks instead of recreating
+	// the links at the destination.
+	FollowSymlinks bool
+	// Force allows copying into an existing, non-empty destination and
+	// overwrites any existing files.
+	Force bool
+}
+
+// CopyDir recursively copies the directory src to dst. Directories and files
+// keep their permissions and symlinks are recreated as they are.
+// It fails if dst exists and is not empty or if dst is inside src.
+func CopyDir(src, dst string) error {
+	return CopyDirWithOpts(src, dst, CopyDirOpts{})
+}
+
+// CopyDirWithOpts is like CopyDir but allows to customize the handling of
+// symlinks and existing destinations.
+func CopyDirWithOpts(src, dst string, opts CopyDirOpts) error {
+	if !IsDir(src) {
+		return fmt.Errorf("not a directory: %q", src)
+	}
+
+	// the copy would keep descending into its own output otherwise
+	inside, err := IsSubPath(src, dst)
+	if err != nil {
+		return err
+	}
+
+	if inside {
+		return fmt.Errorf("can not copy %q into itself at %q", src, dst)
+	}
+
+	if !opts.Force {
+		entries, err := os.ReadDir(dst)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to check destination %q: %w", dst, err)
+		}
+
+		if len(entries) > 0 {
+			return fmt.Errorf("destination %q is not empty", dst)
+		}
+	}
+
+	return copyDir(src, dst, opts, map[string]bool{})
+}
+
+// copyDir copies src to dst. seen contains the resolved paths of all
+// directories currently being copied to detect symlink loops.
+func copyDir(src, dst string, opts CopyDirOpts, seen map[string]bool) error {
+	real, err := filepath.EvalSymlinks(src)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %q: %w", src, err)
+	}
+
+	if seen[real] {
+		return fmt.Errorf("symlink loop detected at %q", src)
+	}
+
+	seen[real] = true
+	defer delete(seen, real)
+
+	fi, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat %q: %w", src, err)
+	}
+
+	// the mode of src is only applied once its content is copied since it
+	// might not allow us to write to dst, an existing dst from an earlier
+	// copy might not either
+	if err := os.Mkdir(dst, 0o700); err != nil {
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to create %q: %w", dst, err)
+		}
+
+		if err := os.Chmod(dst, 0o700); err != nil {
+			return fmt.Errorf("failed to set mode of %q: %w", dst, err)
+		}
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("failed to read dir %q: %w", src, err)
+	}
+
+	for _, e := range entries {
+		sp := filepath.Join(src, e.Name())
+		dp := filepath.Join(dst, e.Name())
+
+		mode := e.Type()
+		if mode&os.ModeSymlink != 0 {
+			if !opts.FollowSymlinks {
+				if err := copySymlink(sp, dp, opts.Force); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			sfi, err := os.Stat(sp)
+			if err != nil {
+				return fmt.Errorf("failed to follow symlink %q: %w", sp, err)
+			}
+
+			mode = sfi.Mode().Type()
+		}
+
+		switch {
+		case mode.IsDir():
+			if err := copyDir(sp, dp, opts, seen); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			copyFn := CopyFile
+			if opts.Force {
+				copyFn = CopyFileForce
+			}
+
+			if err := copyFn(sp, dp); err != nil {
+				return err
+			}
+		default:
+			debug.Log("skipping %s with unsupported mode %s", sp, mode)
+		}
+	}
+
+	// the mode passed to mkdir is subject to the umask
+	if err := os.Chmod(dst, fi.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to set mode of %q: %w", dst, err)
+	}
+
+	return nil
+}
+
+func copySymlink(src, dst string, force bool) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return fmt.Errorf("failed to read symlink %q: %w", src, err)
+	}
+
+	if force {
+		if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove %q: %w", dst, err)
+		}
+	}
+
+	if err := os.Symlink(target, dst); err != nil {
+		return fmt.Errorf("failed to create symlink %q: %w", dst, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+//go:build linux
+// +build linux
+
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyFileRangeChunk is the maximum number of bytes copied by a single
+// copy_file_range call.
+const copyFileRangeChunk = 1 << 30
+
+// copyData copies all data from in to out using copy_file_range, which lets
+// the kernel copy the data (or even reflink it) without passing it through
+// userspace. It falls back to copyBuffered if the syscall is not available
+// for these files.
+func copyData(out, in *os.File) error {
+	for {
+		n, err := unix.CopyFileRange(int(in.Fd()), nil, int(out.Fd()), nil, copyFileRangeChunk, 0)
+		if err != nil {
+			if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) || errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) {
+				// the file offsets have been advanced for anything
+				// copied so far, so we can just continue from there.
+				return copyBuffered(out, in)
+			}
+
+			return fmt.Errorf("failed to copy: %w", err)
+		}
+
+		if n == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package fsutil
+
+import "os"
+
+// copyData copies all data from in to out.
+func copyData(out, in *os.File) error {
+	return copyBuffered(out, in)
+}
@@ -0,0 +1,318 @@
+package fsutil
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyFile(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	require.NoError(t, os.WriteFile(src, []byte("secret"), 0o600))
+	require.NoError(t, os.Chmod(src, 0o600))
+
+	dst := filepath.Join(tempdir, "dst")
+	assert.NoError(t, CopyFile(src, dst))
+
+	buf, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+
+	fi, err := os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	// do not overwrite existing files
+	require.NoError(t, os.WriteFile(src, []byte("new secret"), 0o600))
+	assert.Error(t, CopyFile(src, dst))
+	assert.Error(t, CopyFileMode(src, dst, 0o640))
+
+	buf, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+
+	assert.NoError(t, CopyFileForce(src, dst))
+
+	buf, err = os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "new secret", string(buf))
+
+	// explicit mode
+	dst = filepath.Join(tempdir, "dst2")
+	assert.NoError(t, CopyFileMode(src, dst, 0o640))
+
+	fi, err = os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), fi.Mode().Perm())
+
+	// no partial file is left behind
+	dst = filepath.Join(tempdir, "dst3")
+	assert.Error(t, CopyFile(tempdir, dst))
+	assert.Error(t, CopyFile(filepath.Join(tempdir, "non-existing"), dst))
+	assert.Equal(t, false, IsFile(dst))
+}
+
+func TestCopyDir(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "foo", "bar"), 0o700))
+	require.NoError(t, os.Chmod(filepath.Join(src, "foo"), 0o750))
+	secret := filepath.Join(src, "foo", "bar", "secret.gpg")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0o600))
+	require.NoError(t, os.Chmod(secret, 0o600))
+	require.NoError(t, os.Symlink(filepath.Join("bar", "secret.gpg"), filepath.Join(src, "foo", "link")))
+
+	dst := filepath.Join(tempdir, "dst")
+	require.NoError(t, CopyDir(src, dst))
+
+	fi, err := os.Stat(filepath.Join(dst, "foo", "bar", "secret.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Join(dst, "foo"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o750), fi.Mode().Perm())
+
+	assert.Equal(t, true, IsSymlink(filepath.Join(dst, "foo", "link")))
+
+	// dst is not empty anymore
+	assert.Error(t, CopyDir(src, dst))
+	assert.NoError(t, CopyDirWithOpts(src, dst, CopyDirOpts{Force: true}))
+
+	// follow symlinks
+	dst = filepath.Join(tempdir, "dst2")
+	require.NoError(t, CopyDirWithOpts(src, dst, CopyDirOpts{FollowSymlinks: true}))
+	assert.Equal(t, false, IsSymlink(filepath.Join(dst, "foo", "link")))
+	assert.Equal(t, true, FileContains(filepath.Join(dst, "foo", "link"), "secret"))
+
+	// detect loops
+	require.NoError(t, os.Symlink("..", filepath.Join(src, "foo", "loop")))
+	assert.Error(t, CopyDirWithOpts(src, filepath.Join(tempdir, "dst3"), CopyDirOpts{FollowSymlinks: true}))
+
+	assert.Error(t, CopyDir(secret, filepath.Join(tempdir, "dst4")))
+
+	// never copy a directory into itself
+	assert.Error(t, CopyDir(src, filepath.Join(src, "foo", "copy")))
+	assert.Error(t, CopyDirWithOpts(src, src, CopyDirOpts{Force: true}))
+	assert.Equal(t, false, Exists(filepath.Join(src, "foo", "copy")))
+}
+
+func TestCopyDirReadOnly(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = filepath.Walk(tempdir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && info.IsDir() {
+				_ = os.Chmod(path, 0o700)
+			}
+
+			return nil
+		})
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "foo"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "foo", "secret.gpg"), []byte("secret"), 0o600))
+	require.NoError(t, os.Chmod(filepath.Join(src, "foo"), 0o500))
+	require.NoError(t, os.Chmod(src, 0o500))
+
+	dst := filepath.Join(tempdir, "dst")
+	require.NoError(t, CopyDir(src, dst))
+	assert.Equal(t, true, FileContains(filepath.Join(dst, "foo", "secret.gpg"), "secret"))
+
+	for _, dir := range []string{dst, filepath.Join(dst, "foo")} {
+		fi, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o500), fi.Mode().Perm(), dir)
+	}
+
+	// copying over the read-only copy works as well
+	require.NoError(t, CopyDirWithOpts(src, dst, CopyDirOpts{Force: true}))
+}
+
+func TestCopyFileForceFailure(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("directories can not be opened for reading on windows")
+	}
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	dst := filepath.Join(tempdir, "dst")
+	require.NoError(t, os.WriteFile(dst, []byte("keep"), 0o600))
+
+	// reading a directory fails half way through the copy
+	in, err := os.Open(tempdir)
+	require.NoError(t, err)
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	assert.Error(t, copyReplace(in, dst, 0o600, nil))
+
+	buf, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "keep", string(buf))
+
+	// no temp files are left behind
+	entries, err := os.ReadDir(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestCopyFileHash(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	dst := filepath.Join(tempdir, "dst")
+	buf := make([]byte, 1024*1024+17)
+	_, err = rand.Read(buf)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(src, buf, 0o640))
+	require.NoError(t, os.Chmod(src, 0o640))
+
+	sum, err := CopyFileHash(src, dst, sha256.New())
+	require.NoError(t, err)
+
+	want := sha256.Sum256(buf)
+	assert.Equal(t, hex.EncodeToString(want[:]), sum)
+
+	eq, err := FilesEqual(src, dst)
+	require.NoError(t, err)
+	assert.Equal(t, true, eq)
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(dst)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), fi.Mode().Perm())
+	}
+
+	// like CopyFile it never overwrites dst
+	_, err = CopyFileHash(src, dst, sha256.New())
+	assert.Error(t, err)
+}
+
+func TestCopyFileBuffered(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	buf := make([]byte, 3*1024*1024+17)
+	_, err = rand.Read(buf)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(src, buf, 0o600))
+
+	for name, copyFn := range map[string]func(out, in *os.File) error{
+		"default":  copyData,
+		"buffered": copyBuffered,
+	} {
+		dst := filepath.Join(tempdir, name)
+
+		in, err := os.Open(src)
+		require.NoError(t, err)
+
+		out, err := os.Create(dst)
+		require.NoError(t, err)
+
+		require.NoError(t, copyFn(out, in), name)
+		require.NoError(t, out.Close())
+		require.NoError(t, in.Close())
+
+		eq, err := FilesEqual(src, dst)
+		require.NoError(t, err)
+		assert.Equal(t, true, eq, name)
+	}
+}
+
+func benchmarkCopy(b *testing.B, copyFn func(out, in *os.File) error) {
+	b.Helper()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(b, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	buf := make([]byte, 64*1024*1024)
+	_, err = rand.Read(buf)
+	require.NoError(b, err)
+	require.NoError(b, os.WriteFile(src, buf, 0o600))
+
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		in, err := os.Open(src)
+		require.NoError(b, err)
+
+		out, err := os.Create(filepath.Join(tempdir, "dst"))
+		require.NoError(b, err)
+
+		require.NoError(b, copyFn(out, in))
+
+		_ = out.Close()
+		_ = in.Close()
+	}
+}
+
+func BenchmarkCopyFile(b *testing.B) {
+	benchmarkCopy(b, copyData)
+}
+
+func BenchmarkCopyFileBuffered(b *testing.B) {
+	benchmarkCopy(b, copyBuffered)
+}
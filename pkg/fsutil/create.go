@@ -0,0 +1,80 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// EnsureDir creates the directory path including any missing parents and
+// sets its mode to exactly mode, regardless of the umask. If the directory
+// already exists only the mode is updated. It fails if path exists but is
+// not a directory, including symlinks to directories, since chmod would
+// change the mode of their target.
+func EnsureDir(path string, mode os.FileMode) error {
+	if err := os.MkdirAll(path, mode); err != nil {
+		return fmt.Errorf("failed to create dir %q: %w", path, err)
+	}
+
+	// MkdirAll succeeds if path is a symlink to a directory
+	if !IsDirNoFollow(path) {
+		return fmt.Errorf("not a directory: %q", path)
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to set mode of %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// CreateExclusive creates a new file at path and opens it for writing. The
+// file gets exactly the given mode, regardless of the umask. It fails if
+// path already exists, even if it is a (dangling) symlink, so it will never
+// write to a file planted by someone else.
+func CreateExclusive(path string, mode os.FileMode) (*os.File, error) {
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %q: %w", path, err)
+	}
+
+	if err := fh.Chmod(mode); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(path)
+
+		return nil, fmt.Errorf("failed to set mode of %q: %w", path, err)
+	}
+
+	return fh, nil
+}
+
+// Touch creates an empty file with mode 0600 if path does not exist yet.
+// Otherwise it updates the modification time of path to now.
+func Touch(path string) error {
+	fh, err := CreateExclusive(path, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return SetMtime(path, time.Now())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := fh.Close(); err != nil {
+		return fmt.Errorf("failed to close %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// SetMtime sets the modification time of path to t. The access time is set
+// to t as well.
+func SetMtime(path string, t time.Time) error {
+	if err := os.Chtimes(path, t, t); err != nil {
+		return fmt.Errorf("failed to set mtime of %q: %w", path, err)
+	}
+
+	return nil
+}
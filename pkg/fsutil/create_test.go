@@ -0,0 +1,121 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureDir(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	dir := filepath.Join(tempdir, "foo", "bar")
+	require.NoError(t, EnsureDir(dir, 0o700))
+	assert.Equal(t, true, IsDir(dir))
+
+	// existing dirs are fine
+	require.NoError(t, EnsureDir(dir, 0o700))
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte("foo"), 0o600))
+	assert.Error(t, EnsureDir(fn, 0o700))
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// symlinks are rejected and their target is left alone
+	target := filepath.Join(tempdir, "target")
+	require.NoError(t, os.Mkdir(target, 0o755))
+	require.NoError(t, os.Chmod(target, 0o755))
+
+	link := filepath.Join(tempdir, "link")
+	require.NoError(t, os.Symlink(target, link))
+	assert.Error(t, EnsureDir(link, 0o700))
+
+	fi, err := os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+}
+
+func TestTouch(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "foo")
+	require.NoError(t, Touch(fn))
+
+	fi, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), fi.Size())
+
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	}
+
+	past := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, SetMtime(fn, past))
+
+	fi, err = os.Stat(fn)
+	require.NoError(t, err)
+	assert.True(t, fi.ModTime().Equal(past))
+
+	require.NoError(t, Touch(fn))
+
+	fi, err = os.Stat(fn)
+	require.NoError(t, err)
+	assert.True(t, fi.ModTime().After(past))
+
+	assert.Error(t, Touch(filepath.Join(tempdir, "non-existing", "foo")))
+	assert.Error(t, SetMtime(filepath.Join(tempdir, "non-existing"), past))
+}
+
+func TestCreateExclusive(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret")
+	fh, err := CreateExclusive(fn, 0o600)
+	require.NoError(t, err)
+
+	_, err = fh.WriteString("foo")
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(fn)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	}
+
+	_, err = CreateExclusive(fn, 0o600)
+	assert.ErrorIs(t, err, os.ErrExist)
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf))
+}
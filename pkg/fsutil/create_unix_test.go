@@ -0,0 +1,70 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || aix
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris aix
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureDirUmask(t *testing.T) { //nolint:paralleltest
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	// the umask is process wide, so this test must not run in parallel
+	old := syscall.Umask(0o277)
+	defer syscall.Umask(old)
+
+	dir := filepath.Join(tempdir, "store")
+	require.NoError(t, EnsureDir(dir, 0o700))
+
+	fi, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), fi.Mode().Perm())
+
+	// existing directories are fixed as well
+	require.NoError(t, os.Chmod(dir, 0o755))
+	require.NoError(t, EnsureDir(dir, 0o700))
+
+	fi, err = os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), fi.Mode().Perm())
+}
+
+func TestCreateExclusiveUmask(t *testing.T) { //nolint:paralleltest
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	old := syscall.Umask(0o277)
+	defer syscall.Umask(old)
+
+	fn := filepath.Join(tempdir, "secret")
+	fh, err := CreateExclusive(fn, 0o640)
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+
+	fi, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), fi.Mode().Perm())
+
+	// a dangling symlink is not followed
+	link := filepath.Join(tempdir, "link")
+	require.NoError(t, os.Symlink(filepath.Join(tempdir, "target"), link))
+	_, err = CreateExclusive(link, 0o600)
+	assert.ErrorIs(t, err, os.ErrExist)
+	assert.Equal(t, false, Exists(filepath.Join(tempdir, "target")))
+}
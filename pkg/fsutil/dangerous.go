@@ -0,0 +1,77 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrDangerousPath is returned by the recursive destructive helpers if they
+// are asked to operate on a path where that would most likely be a mistake,
+// see IsDangerousPath.
+var ErrDangerousPath = fmt.Errorf("refusing to operate on a dangerous path")
+
+// IsDangerousPath returns true if path is the root of a filesystem, the home
+// directory of the user, a well-known system directory, the temp dir or the
+// current working directory. Symlinks are resolved before comparing. A store
+// never lives at any of these locations, so removing one of them
+// recursively is almost certainly a bug.
+func IsDangerousPath(path string) bool {
+	p, err := resolveExisting(path)
+	if err != nil {
+		// if we can't tell better be safe
+		return true
+	}
+
+	if filepath.Dir(p) == p {
+		return true
+	}
+
+	for _, d := range dangerousPaths() {
+		if d == "" {
+			continue
+		}
+
+		r, err := resolveExisting(d)
+		if err != nil {
+			continue
+		}
+
+		if samePath(p, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func dangerousPaths() []string {
+	paths := append([]string{os.Getenv("GOPASS_HOMEDIR"), os.TempDir()}, systemDirs()...)
+
+	if hd, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, hd)
+	}
+
+	if wd, err := os.Getwd(); err == nil {
+		paths = append(paths, wd)
+	}
+
+	return paths
+}
+
+// RemoveOpts controls the behaviour of SafeRemoveAllWithOpts and
+// PruneEmptyDirsWithOpts.
+type RemoveOpts struct {
+	// AllowDangerousPath disables the IsDangerousPath check.
+	AllowDangerousPath bool
+}
+
+// checkDangerous returns ErrDangerousPath if path is dangerous and allow is
+// not set.
+func checkDangerous(op, path string, allow bool) error {
+	if !allow && IsDangerousPath(path) {
+		return fmt.Errorf("can not %s %q: %w", op, path, ErrDangerousPath)
+	}
+
+	return nil
+}
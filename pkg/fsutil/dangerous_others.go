@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package fsutil
+
+// systemDirs returns well-known system directories.
+func systemDirs() []string {
+	return []string{
+		"/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib64", "/mnt",
+		"/opt", "/proc", "/root", "/sbin", "/srv", "/sys", "/tmp", "/usr",
+		"/usr/bin", "/usr/lib", "/usr/local", "/var",
+		// macOS
+		"/Applications", "/Library", "/System", "/Users", "/Volumes",
+	}
+}
+
+func samePath(a, b string) bool {
+	return a == b
+}
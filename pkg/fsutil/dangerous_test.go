@@ -0,0 +1,87 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDangerousPath(t *testing.T) {
+	t.Parallel()
+
+	root := "/"
+	if runtime.GOOS == "windows" {
+		root = filepath.VolumeName(os.TempDir()) + `\`
+	}
+
+	hd, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	for _, p := range []string{
+		root,
+		hd,
+		hd + string(filepath.Separator),
+		filepath.Join(hd, "foo", ".."),
+		wd,
+		".",
+		os.TempDir(),
+	} {
+		assert.Equal(t, true, IsDangerousPath(p), p)
+	}
+
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, true, IsDangerousPath("/etc"))
+		assert.Equal(t, true, IsDangerousPath("/usr"))
+	}
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	store := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(store, "empty"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(store, "foo.gpg"), []byte("foo"), 0o600))
+
+	assert.Equal(t, false, IsDangerousPath(tempdir))
+	assert.Equal(t, false, IsDangerousPath(store))
+	assert.Equal(t, false, IsDangerousPath(filepath.Join(store, "non-existing")))
+
+	// the destructive helpers refuse dangerous paths without touching them
+	assert.ErrorIs(t, ShredDir(root, 1), ErrDangerousPath)
+	assert.ErrorIs(t, ShredDir(hd, 1), ErrDangerousPath)
+	assert.ErrorIs(t, SafeRemoveAll(filepath.Dir(hd), hd), ErrDangerousPath)
+
+	_, err = PruneEmptyDirs(root)
+	assert.ErrorIs(t, err, ErrDangerousPath)
+
+	_, err = PruneEmptyDirs(hd)
+	assert.ErrorIs(t, err, ErrDangerousPath)
+
+	_, err = PruneEmptyDirsDryRun(root)
+	assert.ErrorIs(t, err, ErrDangerousPath)
+
+	// a symlink to a dangerous path is dangerous as well
+	if runtime.GOOS != "windows" && IsDir(hd) {
+		link := filepath.Join(tempdir, "home")
+		require.NoError(t, os.Symlink(hd, link))
+		assert.Equal(t, true, IsDangerousPath(link))
+		assert.ErrorIs(t, ShredDir(link, 1), ErrDangerousPath)
+	}
+
+	// regular stores are still fine
+	_, err = PruneEmptyDirsWithOpts(store, RemoveOpts{AllowDangerousPath: true})
+	require.NoError(t, err)
+	assert.Equal(t, false, Exists(filepath.Join(store, "empty")))
+	require.NoError(t, ShredDir(store, 1))
+	assert.Equal(t, false, Exists(store))
+}
@@ -0,0 +1,32 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// systemDirs returns well-known system directories.
+func systemDirs() []string {
+	dirs := []string{}
+
+	for _, env := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramData", "USERPROFILE", "APPDATA", "LOCALAPPDATA"} {
+		if d := os.Getenv(env); d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+
+	if sd := os.Getenv("SystemDrive"); sd != "" {
+		dirs = append(dirs, filepath.Join(sd+`\`, "Users"))
+	}
+
+	return dirs
+}
+
+// samePath compares both paths case-insensitive, like Windows does.
+func samePath(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
@@ -0,0 +1,64 @@
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Dir provides read-only access to the directory tree rooted at the given
+// path. It implements fs.FS, fs.ReadDirFS and fs.StatFS. Names must be
+// valid according to fs.ValidPath, so names escaping the root using ..
+// are rejected. On Windows names containing \ or : are rejected as well.
+type Dir string
+
+var (
+	_ fs.ReadDirFS = Dir("")
+	_ fs.StatFS    = Dir("")
+)
+
+// Open implements fs.FS.
+func (d Dir) Open(name string) (fs.File, error) {
+	fp, err := d.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	fh, err := os.Open(fp)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return fh, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (d Dir) ReadDir(name string) ([]fs.DirEntry, error) {
+	fp, err := d.join("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadDir(fp) //nolint:wrapcheck
+}
+
+// Stat implements fs.StatFS.
+func (d Dir) Stat(name string) (fs.FileInfo, error) {
+	fp, err := d.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Stat(fp) //nolint:wrapcheck
+}
+
+func (d Dir) join(op, name string) (string, error) {
+	// like os.DirFS, never treat these as separators or volume names
+	if !fs.ValidPath(name) || runtime.GOOS == "windows" && strings.ContainsAny(name, `\:`) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	return filepath.Join(string(d), filepath.FromSlash(name)), nil
+}
@@ -0,0 +1,58 @@
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	root := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "foo", "bar"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gpg-id"), []byte("0xDEADBEEF"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "foo", "baz.gpg"), []byte("baz"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "foo", "bar", "zab.gpg"), []byte("zab"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "outside"), []byte("outside"), 0o600))
+
+	fsys := Dir(root)
+	require.NoError(t, fstest.TestFS(fsys, ".gpg-id", "foo/baz.gpg", "foo/bar/zab.gpg"))
+
+	var files []string
+	require.NoError(t, fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+
+		return nil
+	}))
+	assert.Equal(t, []string{".gpg-id", "foo/bar/zab.gpg", "foo/baz.gpg"}, files)
+
+	for _, name := range []string{"../outside", "foo/../../outside", "/etc/passwd"} {
+		_, err := fsys.Open(name)
+		assert.ErrorIs(t, err, fs.ErrInvalid, name)
+
+		_, err = fsys.Stat(name)
+		assert.ErrorIs(t, err, fs.ErrInvalid, name)
+
+		_, err = fsys.ReadDir(name)
+		assert.ErrorIs(t, err, fs.ErrInvalid, name)
+	}
+}
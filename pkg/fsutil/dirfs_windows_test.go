@@ -0,0 +1,48 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirWindowsNames(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	root := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(root, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "outside"), []byte("outside"), 0o600))
+
+	fsys := Dir(root)
+
+	for _, name := range []string{
+		`..\outside`,
+		`foo\..\..\outside`,
+		`C:outside`,
+		`C:\outside`,
+		`\\?\C:\outside`,
+	} {
+		_, err := fs.ReadFile(fsys, name)
+		assert.ErrorIs(t, err, fs.ErrInvalid, name)
+
+		_, err = fs.Stat(fsys, name)
+		assert.ErrorIs(t, err, fs.ErrInvalid, name)
+
+		_, err = fs.ReadDir(fsys, name)
+		assert.ErrorIs(t, err, fs.ErrInvalid, name)
+	}
+}
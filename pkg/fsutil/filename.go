@@ -0,0 +1,207 @@
+package fsutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxFilenameLen is the maximum length of a single path component in bytes
+// supported by most filesystems.
+const MaxFilenameLen = 255
+
+// maxExtLen is the maximum length of a file extension (without the dot)
+// that CleanFilenameKeepExt will preserve.
+const maxExtLen = 10
+
+var reCleanFilename = regexp.MustCompile(`[^\w\d@.-]`)
+
+// CleanFilename strips all possibly suspicious characters from a filename
+// WARNING: NOT suiteable for pathnames as slashes will be stripped as well!
+func CleanFilename(in string) string {
+	return strings.Trim(reCleanFilename.ReplaceAllString(in, "_"), "_ ")
+}
+
+// CleanFilenameKeepExt is like CleanFilename but cleans the extension
+// separately and keeps the dot in front of it. Dotfiles or overly long
+// extensions are treated as part of the name.
+func CleanFilenameKeepExt(name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	if ext == "" || base == "" || len(ext) > maxExtLen+1 {
+		return CleanFilename(name)
+	}
+
+	cext := CleanFilename(ext[1:])
+	if cext == "" {
+		return CleanFilename(base)
+	}
+
+	return CleanFilename(base) + "." + cext
+}
+
+// CleanFilenameWith is like CleanFilename but replaces suspicious characters
+// with repl. If collapse is true, a run of consecutive suspicious characters
+// is replaced by a single repl and no repl is inserted at the start or the
+// end of the name. Occurrences of repl that were part of name already are
+// always kept. A repl that is not safe in a filename itself, i.e. a path
+// separator, a space or a control character, is replaced by an underscore.
+func CleanFilenameWith(name string, repl rune, collapse bool) string {
+	if !isSafeRepl(repl) {
+		repl = '_'
+	}
+
+	var sb strings.Builder
+
+	pending := false
+
+	for _, c := range name {
+		if !reCleanFilename.MatchString(string(c)) {
+			if pending && sb.Len() > 0 {
+				sb.WriteRune(repl)
+			}
+
+			sb.WriteRune(c)
+
+			pending = false
+
+			continue
+		}
+
+		if collapse {
+			pending = true
+
+			continue
+		}
+
+		sb.WriteRune(repl)
+	}
+
+	return sb.String()
+}
+
+func isSafeRepl(r rune) bool {
+	if r == utf8.RuneError || r == '/' || r == '\\' {
+		return false
+	}
+
+	return !unicode.IsSpace(r) && !unicode.IsControl(r)
+}
+
+// CleanFilenameTranslit is like CleanFilename but tries to transliterate
+// non-ASCII characters first, e.g. Müller becomes Muller instead of M_ller.
+// It decomposes the name (NFKD) and removes all combining marks. Anything
+// that is still not allowed afterwards is replaced as usual.
+func CleanFilenameTranslit(name string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)))
+
+	out, _, err := transform.String(t, name)
+	if err != nil {
+		return CleanFilename(name)
+	}
+
+	return CleanFilename(out)
+}
+
+// CleanFilenameMax is like CleanFilename but limits the result to max bytes.
+// If the name needs to be shortened a dash and the first eight hex digits of
+// the SHA-256 of the original name are appended, so that different long
+// names with a common prefix do not collide. A max below one falls back to
+// MaxFilenameLen.
+func CleanFilenameMax(name string, max int) string {
+	if max < 1 {
+		max = MaxFilenameLen
+	}
+
+	out := CleanFilename(name)
+	if len(out) <= max {
+		return out
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+
+	if max <= len(suffix) {
+		return suffix[len(suffix)-max:]
+	}
+
+	return truncateUTF8(out, max-len(suffix)) + suffix
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+// CleanFilenamePortable is like CleanFilename but also makes sure the name
+// can be used on Windows. Reserved device names like CON or NUL.txt get an
+// underscore prepended and trailing dots, which Windows silently strips,
+// are removed.
+func CleanFilenamePortable(name string) string {
+	out := strings.TrimRight(CleanFilename(name), ". ")
+	if isReservedName(out) {
+		return "_" + out
+	}
+
+	return out
+}
+
+// isReservedName returns true if the name refers to a Windows device, with
+// or without extension.
+func isReservedName(name string) bool {
+	base := strings.ToUpper(name)
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+
+	switch base {
+	case "CON", "PRN", "AUX", "NUL":
+		return true
+	}
+
+	if len(base) == 4 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) {
+		return base[3] >= '1' && base[3] <= '9'
+	}
+
+	return false
+}
+
+// ValidFilename returns an error describing why name can not be used as a
+// portable filename, or nil if it can. Unlike CleanFilenamePortable it
+// does not try to fix the name.
+func ValidFilename(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("filename must not be empty")
+	case len(name) > MaxFilenameLen:
+		return fmt.Errorf("filename must not be longer than %d bytes, got %d", MaxFilenameLen, len(name))
+	case name == "." || name == "..":
+		return fmt.Errorf("filename must not be %q", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("filename %q must not contain path separators", name)
+	case strings.HasSuffix(name, ".") || strings.HasSuffix(name, " "):
+		return fmt.Errorf("filename %q must not end with a dot or space", name)
+	case isReservedName(name):
+		return fmt.Errorf("filename %q is a reserved device name on Windows", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package fsutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanFilename(t *testing.T) {
+	t.Parallel()
+
+	m := map[string]string{
+		`"§$%&aÜÄ*&b%§"'Ä"c%$"'"`: "a____b______c",
+	}
+	for k, v := range m {
+		out := CleanFilename(k)
+		t.Logf("%s -> %s / %s", k, v, out)
+
+		assert.Equal(t, v, out)
+	}
+}
+
+func TestCleanFilenameKeepExt(t *testing.T) {
+	t.Parallel()
+
+	for in, out := range map[string]string{
+		"report.v2.pdf":           "report.v2.pdf",
+		"my report (final).pdf":   "my_report__final.pdf",
+		"no extension":            "no_extension",
+		".env":                    ".env",
+		"foo.p d f":               "foo.p_d_f",
+		"foo.ÜÄ":                  "foo",
+		"foo.verylongextension":   "foo.verylongextension",
+		"foo.very long extension": "foo.very_long_extension",
+		"foo":                     "foo",
+	} {
+		assert.Equal(t, out, CleanFilenameKeepExt(in), in)
+	}
+}
+
+func TestCleanFilenameWith(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in       string
+		repl     rune
+		collapse bool
+		out      string
+	}{
+		{`"§$%&aÜÄ*&b%§"'Ä"c%$"'"`, '_', false, "_____a____b______c_____"},
+		{`"§$%&aÜÄ*&b%§"'Ä"c%$"'"`, '_', true, "a_b_c"},
+		{"report v2", '-', true, "report-v2"},
+		{"  report -- v2  ", '-', true, "report----v2"},
+		{"a__b", '_', true, "a__b"},
+		{"a_ _b", '_', true, "a___b"},
+		{"a  b", '_', true, "a_b"},
+		{"  report  v2  ", '-', false, "--report--v2--"},
+		{"-a-", '-', false, "-a-"},
+		{"-a-", '-', true, "-a-"},
+		{" -a- ", '-', true, "-a-"},
+		{"report v2", ' ', true, "report_v2"},
+		{"report v2", '/', true, "report_v2"},
+		{"report v2 (final)", '·', true, "report·v2·final"},
+		{"ÜÄÖ", '-', true, ""},
+	} {
+		assert.Equal(t, tc.out, CleanFilenameWith(tc.in, tc.repl, tc.collapse), tc.in)
+	}
+}
+
+func TestCleanFilenameTranslit(t *testing.T) {
+	t.Parallel()
+
+	for in, out := range map[string]string{
+		"Müller":         "Muller",
+		"Grüße aus Köln": "Gru_e_aus_Koln",
+		"ÄÖÜäöü":         "AOUaou",
+		"café crème":     "cafe_creme",
+		"Français":       "Francais",
+		"key🔑.txt":       "key_.txt",
+		"ﬁle":            "file",
+	} {
+		assert.Equal(t, out, CleanFilenameTranslit(in), in)
+	}
+}
+
+func TestCleanFilenameMax(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "foo", CleanFilenameMax("foo", MaxFilenameLen))
+	assert.Equal(t, "foo", CleanFilenameMax("foo", 0))
+
+	long := strings.Repeat("a", 1000)
+	out := CleanFilenameMax(long, MaxFilenameLen)
+	assert.Len(t, out, MaxFilenameLen)
+	assert.True(t, strings.HasPrefix(out, strings.Repeat("a", 200)))
+
+	// long names with a common prefix must not collide
+	other := CleanFilenameMax(long+"b", MaxFilenameLen)
+	assert.Len(t, other, MaxFilenameLen)
+	assert.NotEqual(t, out, other)
+
+	// stable output
+	assert.Equal(t, out, CleanFilenameMax(long, MaxFilenameLen))
+
+	assert.Len(t, CleanFilenameMax(long, 5), 5)
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "aä", truncateUTF8("aäb", 3))
+	assert.Equal(t, "a", truncateUTF8("aäb", 2))
+	assert.Equal(t, "", truncateUTF8("äb", 1))
+	assert.Equal(t, "ab", truncateUTF8("ab", 5))
+}
+
+func TestCleanFilenamePortable(t *testing.T) {
+	t.Parallel()
+
+	for in, out := range map[string]string{
+		"CON":         "_CON",
+		"con":         "_con",
+		"NUL.txt":     "_NUL.txt",
+		"nul.tar.gz":  "_nul.tar.gz",
+		"Aux":         "_Aux",
+		"PRN.":        "_PRN",
+		"COM1":        "_COM1",
+		"lpt9.log":    "_lpt9.log",
+		"COM0":        "COM0",
+		"COM10":       "COM10",
+		"CONSOLE":     "CONSOLE",
+		"connection":  "connection",
+		"foo...":      "foo",
+		"foo. . ":     "foo._",
+		"github.gpg":  "github.gpg",
+		"Müller.gpg ": "M_ller.gpg",
+	} {
+		assert.Equal(t, out, CleanFilenamePortable(in), in)
+	}
+}
+
+func TestValidFilename(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{
+		"",
+		strings.Repeat("a", 256),
+		".",
+		"..",
+		"foo/bar",
+		`foo\bar`,
+		"foo.",
+		"foo ",
+		"CON",
+		"nul.txt",
+		"Com1",
+	} {
+		assert.Error(t, ValidFilename(name), name)
+	}
+
+	for _, name := range []string{
+		"foo.gpg",
+		".gpg-id",
+		"CONSOLE",
+		"com0",
+		strings.Repeat("a", 255),
+	} {
+		assert.NoError(t, ValidFilename(name), name)
+	}
+}
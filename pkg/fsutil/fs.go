@@ -0,0 +1,52 @@
+package fsutil
+
+import "os"
+
+// FS is the subset of the filesystem helpers in this package that can be
+// replaced, e.g. with a MemFS in tests.
+type FS interface {
+	CleanPath(path string) string
+	IsDir(path string) bool
+	IsFile(path string) bool
+	ReadFile(path string) ([]byte, error)
+	WriteFileAtomic(path string, data []byte, mode os.FileMode) error
+	Shred(path string, runs int) error
+}
+
+// Default is the FS used by callers that accept an injectable FS. It is
+// backed by the real filesystem.
+var Default FS = OSFS{}
+
+// OSFS implements FS using the functions of this package on the real
+// filesystem.
+type OSFS struct{}
+
+// CleanPath calls CleanPath.
+func (OSFS) CleanPath(path string) string {
+	return CleanPath(path)
+}
+
+// IsDir calls IsDir.
+func (OSFS) IsDir(path string) bool {
+	return IsDir(path)
+}
+
+// IsFile calls IsFile.
+func (OSFS) IsFile(path string) bool {
+	return IsFile(path)
+}
+
+// ReadFile calls os.ReadFile.
+func (OSFS) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(path) //nolint:wrapcheck
+}
+
+// WriteFileAtomic calls WriteFileAtomic.
+func (OSFS) WriteFileAtomic(path string, data []byte, mode os.FileMode) error {
+	return WriteFileAtomic(path, data, mode)
+}
+
+// Shred calls Shred.
+func (OSFS) Shred(path string, runs int) error {
+	return Shred(path, runs)
+}
@@ -0,0 +1,30 @@
+//go:build openbsd
+// +build openbsd
+
+package fsutil
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// FilesystemType returns the name of the filesystem path lives on, e.g.
+// ffs or nfs.
+func FilesystemType(path string) (string, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return "", fmt.Errorf("failed to statfs %q: %w", path, err)
+	}
+
+	name := make([]byte, 0, len(st.F_fstypename))
+	for _, c := range st.F_fstypename {
+		if c == 0 {
+			break
+		}
+
+		name = append(name, byte(c))
+	}
+
+	return string(name), nil
+}
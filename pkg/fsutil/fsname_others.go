@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !openbsd && !windows
+// +build !linux,!darwin,!freebsd,!openbsd,!windows
+
+package fsutil
+
+// FilesystemType is not supported on this platform.
+func FilesystemType(path string) (string, error) {
+	return "", ErrNotSupported
+}
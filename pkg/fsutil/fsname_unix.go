@@ -0,0 +1,21 @@
+//go:build darwin || freebsd
+// +build darwin freebsd
+
+package fsutil
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// FilesystemType returns the name of the filesystem path lives on, e.g.
+// apfs or nfs.
+func FilesystemType(path string) (string, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return "", fmt.Errorf("failed to statfs %q: %w", path, err)
+	}
+
+	return unix.ByteSliceToString(st.Fstypename[:]), nil
+}
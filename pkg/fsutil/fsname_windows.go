@@ -0,0 +1,42 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// FilesystemType returns the name of the filesystem of the volume path lives
+// on, e.g. ntfs.
+func FilesystemType(path string) (string, error) {
+	root, err := volumeRoot(path)
+	if err != nil {
+		return "", err
+	}
+
+	name := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumeInformation(root, nil, 0, nil, nil, nil, &name[0], uint32(len(name))); err != nil {
+		return "", fmt.Errorf("failed to get volume information of %q: %w", path, err)
+	}
+
+	return strings.ToLower(windows.UTF16ToString(name)), nil
+}
+
+// volumeRoot returns the root of the volume containing path, e.g. C:\.
+func volumeRoot(path string) (*uint16, error) {
+	p, err := windows.UTF16PtrFromString(fixLongPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+
+	root := make([]uint16, windows.MAX_PATH+1)
+	if err := windows.GetVolumePathName(p, &root[0], uint32(len(root))); err != nil {
+		return nil, fmt.Errorf("failed to get volume of %q: %w", path, err)
+	}
+
+	return &root[0], nil
+}
@@ -0,0 +1,177 @@
+//go:build linux
+// +build linux
+
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+	"golang.org/x/sys/unix"
+)
+
+// filesystem magic numbers as reported by statfs(2). Not all of them are
+// defined in x/sys/unix.
+const (
+	magicBtrfs    = 0x9123683e
+	magicZFS      = 0x2fc12fc1
+	magicBcachefs = 0xca451a4e
+	magicNTFS     = 0x5346544e
+)
+
+// IsCoWFilesystem returns true if the given path lives on a copy-on-write
+// filesystem (btrfs, ZFS or bcachefs). Overwriting a file in place on such
+// a filesystem allocates new blocks and leaves the old content on disk.
+func IsCoWFilesystem(path string) (bool, error) {
+	magic, err := fsMagic(path)
+	if err != nil {
+		return false, err
+	}
+
+	switch magic {
+	case magicBtrfs, magicZFS, magicBcachefs:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// IsTmpfs returns true if the given path lives on a tmpfs, i.e. its content
+// is kept in memory only. This is just a hint: tmpfs pages can still be
+// swapped out to disk unless swap is encrypted or disabled.
+func IsTmpfs(path string) (bool, error) {
+	magic, err := fsMagic(path)
+	if err != nil {
+		return false, err
+	}
+
+	return magic == unix.TMPFS_MAGIC, nil
+}
+
+// fsNames maps the filesystem magic numbers to their names.
+var fsNames = map[uint32]string{
+	unix.AFS_SUPER_MAGIC:       "afs",
+	unix.AUTOFS_SUPER_MAGIC:    "autofs",
+	magicBcachefs:              "bcachefs",
+	magicBtrfs:                 "btrfs",
+	unix.CEPH_SUPER_MAGIC:      "ceph",
+	unix.CIFS_SUPER_MAGIC:      "cifs",
+	unix.ECRYPTFS_SUPER_MAGIC:  "ecryptfs",
+	unix.EXFAT_SUPER_MAGIC:     "exfat",
+	unix.EXT4_SUPER_MAGIC:      "ext4",
+	unix.F2FS_SUPER_MAGIC:      "f2fs",
+	unix.FUSE_SUPER_MAGIC:      "fuse",
+	unix.ISOFS_SUPER_MAGIC:     "iso9660",
+	unix.MSDOS_SUPER_MAGIC:     "vfat",
+	unix.NFS_SUPER_MAGIC:       "nfs",
+	magicNTFS:                  "ntfs",
+	unix.OVERLAYFS_SUPER_MAGIC: "overlay",
+	unix.PROC_SUPER_MAGIC:      "proc",
+	unix.RAMFS_MAGIC:           "ramfs",
+	unix.REISERFS_SUPER_MAGIC:  "reiserfs",
+	unix.SMB2_SUPER_MAGIC:      "smb2",
+	unix.SMB_SUPER_MAGIC:       "smb",
+	unix.SQUASHFS_MAGIC:        "squashfs",
+	unix.SYSFS_MAGIC:           "sysfs",
+	unix.TMPFS_MAGIC:           "tmpfs",
+	unix.UDF_SUPER_MAGIC:       "udf",
+	unix.V9FS_MAGIC:            "9p",
+	unix.XFS_SUPER_MAGIC:       "xfs",
+	magicZFS:                   "zfs",
+}
+
+// FilesystemType returns the name of the filesystem path lives on, e.g.
+// ext4 or nfs. Unknown filesystems are reported by their magic number in
+// hex.
+func FilesystemType(path string) (string, error) {
+	magic, err := fsMagic(path)
+	if err != nil {
+		return "", err
+	}
+
+	if magic == unix.FUSE_SUPER_MAGIC {
+		// all FUSE filesystems share the same magic, but the mount table
+		// knows the actual type, e.g. fuse.sshfs
+		if name := mountType(path); name != "" {
+			return name, nil
+		}
+	}
+
+	if name, found := fsNames[magic]; found {
+		return name, nil
+	}
+
+	return fmt.Sprintf("0x%x", magic), nil
+}
+
+// mountType returns the filesystem type recorded in the mount table for the
+// mount point closest to path, or the empty string if it can not be
+// determined.
+func mountType(path string) string {
+	p, err := CleanPathResolve(path)
+	if err != nil {
+		return ""
+	}
+
+	buf, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		debug.Log("failed to read mount table: %s", err)
+
+		return ""
+	}
+
+	var best, name string
+
+	for _, line := range strings.Split(string(buf), "\n") {
+		// device mountpoint type options dump pass
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+
+		mnt := unescapeMount(fields[1])
+		if len(mnt) >= len(best) && isLexicalSubPath(mnt, p) {
+			best, name = mnt, fields[2]
+		}
+	}
+
+	return name
+}
+
+// unescapeMount replaces the octal escapes (e.g. \040 for space) used in
+// the mount table.
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var sb strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) && isOctal(s[i+1]) && isOctal(s[i+2]) && isOctal(s[i+3]) {
+			sb.WriteByte((s[i+1]-'0')<<6 | (s[i+2]-'0')<<3 | (s[i+3] - '0'))
+			i += 3
+
+			continue
+		}
+
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+func fsMagic(path string) (uint32, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("failed to statfs %q: %w", path, err)
+	}
+
+	return uint32(st.Type), nil
+}
@@ -0,0 +1,27 @@
+//go:build linux
+// +build linux
+
+package fsutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnescapeMount(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"/":                    "/",
+		"/mnt/my\\040store":    "/mnt/my store",
+		"/mnt/tab\\011x\\134y": "/mnt/tab\tx\\y",
+		"/mnt/short\\04":       "/mnt/short\\04",
+		"/mnt/invalid\\089":    "/mnt/invalid\\089",
+	} {
+		assert.Equal(t, want, unescapeMount(in), in)
+	}
+
+	// the root is always mounted
+	assert.NotEmpty(t, mountType("/"))
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package fsutil
+
+// IsCoWFilesystem is only implemented on Linux. On other platforms it always
+// returns false.
+func IsCoWFilesystem(path string) (bool, error) {
+	return false, nil
+}
+
+// IsTmpfs is only implemented on Linux. On other platforms it always
+// returns false.
+func IsTmpfs(path string) (bool, error) {
+	return false, nil
+}
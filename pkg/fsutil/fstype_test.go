@@ -0,0 +1,103 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCoWFilesystem(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	cow, err := IsCoWFilesystem(tempdir)
+	require.NoError(t, err)
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+
+	err = ShredWithOpts(fn, ShredOpts{Rounds: 2, FailIneffective: true})
+	if cow {
+		assert.ErrorIs(t, err, ErrShredIneffective)
+		assert.Equal(t, true, IsFile(fn))
+	} else {
+		assert.NoError(t, err)
+		assert.Equal(t, false, IsFile(fn))
+	}
+}
+
+func TestIsTmpfs(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		tmpfs, err := IsTmpfs(os.TempDir())
+		require.NoError(t, err)
+		assert.Equal(t, false, tmpfs)
+
+		return
+	}
+
+	if !IsDir("/dev/shm") {
+		t.Skip("/dev/shm not available")
+	}
+
+	tmpfs, err := IsTmpfs("/dev/shm")
+	require.NoError(t, err)
+	assert.Equal(t, true, tmpfs)
+
+	tmpfs, err = IsTmpfs("/proc")
+	require.NoError(t, err)
+	assert.Equal(t, false, tmpfs)
+
+	_, err = IsTmpfs("/non-existing")
+	assert.Error(t, err)
+}
+
+func TestFilesystemType(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	name, err := FilesystemType(tempdir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, name)
+
+	_, err = FilesystemType(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+
+	if runtime.GOOS == "linux" && IsDir("/proc/self") {
+		name, err := FilesystemType("/proc/self")
+		require.NoError(t, err)
+		assert.Equal(t, "proc", name)
+	}
+}
+
+func TestIsNetworkFS(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	remote, err := IsNetworkFS(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, false, remote)
+}
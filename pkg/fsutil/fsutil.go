@@ -2,54 +2,22 @@ package fsutil
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
-	"io"
-	"math/rand"
 	"os"
-	"os/user"
 	"path/filepath"
-	"regexp"
 	"strings"
-	"time"
 
 	"github.com/gopasspw/gopass/pkg/debug"
 )
 
-var reCleanFilename = regexp.MustCompile(`[^\w\d@.-]`)
-
-// CleanFilename strips all possibly suspicious characters from a filename
-// WARNING: NOT suiteable for pathnames as slashes will be stripped as well!
-func CleanFilename(in string) string {
-	return strings.Trim(reCleanFilename.ReplaceAllString(in, "_"), "_ ")
-}
-
-// CleanPath resolves common aliases in a path and cleans it as much as possible.
-func CleanPath(path string) string {
-	// http://stackoverflow.com/questions/17609732/expand-tilde-to-home-directory
-	// TODO(GH-2083): We should consider if we really want to rewrite ~
-	if len(path) > 1 && path[:2] == "~/" {
-		usr, _ := user.Current()
-		dir := usr.HomeDir
-
-		if hd := os.Getenv("GOPASS_HOMEDIR"); hd != "" {
-			dir = hd
-		}
-
-		path = strings.Replace(path, "~/", dir+"/", 1)
-	}
-
-	if p, err := filepath.Abs(path); err == nil {
-		return p
-	}
-
-	return filepath.Clean(path)
-}
+// ErrNotSupported is returned by functions that are not available on the
+// current platform.
+var ErrNotSupported = fmt.Errorf("not supported on this platform")
 
 // IsDir checks if a certain path exists and is a directory.
 // https://stackoverflow.com/questions/10510691/how-to-check-whether-a-file-or-directory-denoted-by-a-path-exists-in-golang
 func IsDir(path string) bool {
-	fi, err := os.Stat(path)
+	fi, err := os.Stat(fixLongPath(path))
 	if err != nil {
 		if os.IsNotExist(err) {
 			// not found
@@ -66,7 +34,7 @@ func IsDir(path string) bool {
 
 // IsFile checks if a certain path is actually a file.
 func IsFile(path string) bool {
-	fi, err := os.Stat(path)
+	fi, err := os.Stat(fixLongPath(path))
 	if err != nil {
 		if os.IsNotExist(err) {
 			// not found
@@ -81,9 +49,170 @@ func IsFile(path string) bool {
 	return fi.Mode().IsRegular()
 }
 
+// Exists checks if a certain path exists, following symlinks. It returns
+// false if stat fails for any reason.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		debug.Log("failed to check %s: %s\n", path, err)
+	}
+
+	return err == nil
+}
+
+// NotExist checks if a certain path definitely does not exist. Unlike
+// !Exists it returns false if stat fails for any other reason, e.g. missing
+// permissions.
+func NotExist(path string) bool {
+	_, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		debug.Log("failed to check %s: %s\n", path, err)
+	}
+
+	return os.IsNotExist(err)
+}
+
+// ErrSameFile is returned when copying or moving a file onto itself.
+var ErrSameFile = fmt.Errorf("source and destination are the same file")
+
+// SameFile returns true if a and b refer to the same file after following
+// symlinks, e.g. because one is a hard link or a symlink to the other. It
+// returns false if either of them does not exist.
+func SameFile(a, b string) (bool, error) {
+	fa, err := os.Stat(fixLongPath(a))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to stat %q: %w", a, err)
+	}
+
+	fb, err := os.Stat(fixLongPath(b))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to stat %q: %w", b, err)
+	}
+
+	return os.SameFile(fa, fb), nil
+}
+
+// IsSymlink checks if a certain path is a symlink. Unlike IsDir and IsFile
+// it does not follow the link.
+func IsSymlink(path string) bool {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug.Log("failed to check symlink %s: %s\n", path, err)
+		}
+
+		return false
+	}
+
+	return fi.Mode()&os.ModeSymlink != 0
+}
+
+// IsDirNoFollow is like IsDir but does not follow symlinks, i.e. a symlink
+// pointing to a directory is not reported as a directory.
+func IsDirNoFollow(path string) bool {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug.Log("failed to check dir %s: %s\n", path, err)
+		}
+
+		return false
+	}
+
+	return fi.IsDir()
+}
+
+// IsFileNoFollow is like IsFile but does not follow symlinks, i.e. a symlink
+// pointing to a file is not reported as a file.
+func IsFileNoFollow(path string) bool {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug.Log("failed to check file %s: %s\n", path, err)
+		}
+
+		return false
+	}
+
+	return fi.Mode().IsRegular()
+}
+
+// IsRegular is like IsFileNoFollow. It returns true only for regular files,
+// not for symlinks, directories, FIFOs, sockets or devices.
+func IsRegular(path string) bool {
+	return IsFileNoFollow(path)
+}
+
+// IsFifo checks if a certain path is a named pipe. Reading from a FIFO
+// blocks until a writer shows up, so these should be skipped when walking
+// a store.
+func IsFifo(path string) bool {
+	return lstatMode(path)&os.ModeNamedPipe != 0
+}
+
+// IsSocket checks if a certain path is a Unix domain socket.
+func IsSocket(path string) bool {
+	return lstatMode(path)&os.ModeSocket != 0
+}
+
+// IsDevice checks if a certain path is a block or character device.
+func IsDevice(path string) bool {
+	return lstatMode(path)&os.ModeDevice != 0
+}
+
+// lstatMode returns the mode of path without following symlinks or zero
+// if it can not be determined. Note that a zero mode denotes a regular file,
+// so it must only be used to check for the type bits.
+func lstatMode(path string) os.FileMode {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			debug.Log("failed to check %s: %s\n", path, err)
+		}
+
+		return 0
+	}
+
+	return fi.Mode()
+}
+
 // IsEmptyDir checks if a certain path is an empty directory.
 func IsEmptyDir(path string) (bool, error) {
+	return IsEmptyDirFunc(path, func(string) bool { return false })
+}
+
+// IsEmptyDirFunc is like IsEmptyDir but skips every file or directory below
+// path for which ignore returns true. ignore is called with the base name of
+// each entry, e.g. to skip .git or .gpg-id.
+func IsEmptyDirFunc(path string, ignore func(name string) bool) (bool, error) {
+	return IsEmptyDirWithOpts(path, IsEmptyDirOpts{Ignore: ignore})
+}
+
+// IsEmptyDirOpts controls the behaviour of IsEmptyDirWithOpts.
+type IsEmptyDirOpts struct {
+	// Ignore skips every file or directory for which it returns true, see
+	// IsEmptyDirFunc.
+	Ignore func(name string) bool
+	// IgnoreBrokenSymlinks does not count symlinks pointing to a non-existing
+	// target as content.
+	IgnoreBrokenSymlinks bool
+	// Ignorer skips all matching entries like Ignore. If nil the
+	// DefaultIgnorer is used.
+	Ignorer *Ignorer
+}
+
+// IsEmptyDirWithOpts is like IsEmptyDir but behaves according to opts.
+func IsEmptyDirWithOpts(path string, opts IsEmptyDirOpts) (bool, error) {
 	empty := true
+	ign := opts.Ignorer.orDefault()
 
 	if err := filepath.Walk(path, func(fp string, fi os.FileInfo, ferr error) error {
 		if ferr != nil {
@@ -92,6 +221,16 @@ func IsEmptyDir(path string) (bool, error) {
 		if fi.IsDir() && (fi.Name() == "." || fi.Name() == "..") {
 			return filepath.SkipDir
 		}
+		if fp != path && (ign.Ignore(fi.Name()) || opts.Ignore != nil && opts.Ignore(fi.Name())) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+		if opts.IgnoreBrokenSymlinks && fi.Mode()&os.ModeSymlink != 0 && isBrokenSymlink(fp) {
+			return nil
+		}
 		if !fi.IsDir() {
 			empty = false
 		}
@@ -104,84 +243,96 @@ func IsEmptyDir(path string) (bool, error) {
 	return empty, nil
 }
 
-// Shred overwrite the given file any number of times.
-func Shred(path string, runs int) error {
-	rand.Seed(time.Now().UnixNano())
+// isBrokenSymlink returns true if the target of the symlink at path does not
+// exist.
+func isBrokenSymlink(path string) bool {
+	_, err := os.Stat(path)
 
-	fh, err := os.OpenFile(path, os.O_WRONLY, 0o600)
-	if err != nil {
-		return fmt.Errorf("failed to open file %q: %w", path, err)
-	}
+	return os.IsNotExist(err)
+}
 
-	// ignore the error. this is only taking effect if we error out.
-	defer func() {
-		_ = fh.Close()
-	}()
+// PruneEmptyDirs removes all empty directories below root, starting from the
+// bottom, so directories that only contain empty directories are removed as
+// well. root itself is never removed and .git directories are left alone.
+// The same holds for entries matched by the DefaultIgnorer: ignored
+// directories are neither descended into nor removed, and ignored files keep
+// their directory from being removed, since that would remove them too.
+// It returns the list of removed directories. ErrDangerousPath is returned if
+// root is a dangerous path, see IsDangerousPath.
+func PruneEmptyDirs(root string) ([]string, error) {
+	return PruneEmptyDirsWithOpts(root, RemoveOpts{})
+}
 
-	fi, err := fh.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to stat file %q: %w", path, err)
+// PruneEmptyDirsWithOpts is like PruneEmptyDirs but behaves according to
+// opts.
+func PruneEmptyDirsWithOpts(root string, opts RemoveOpts) ([]string, error) {
+	if err := checkDangerous("prune", root, opts.AllowDangerousPath); err != nil {
+		return nil, err
 	}
 
-	flen := fi.Size()
-
-	// overwrite using pseudo-random data n-1 times and
-	// use zeros in the last iteration
-	bufFn := func() []byte {
-		buf := make([]byte, 1024)
-		_, _ = rand.Read(buf)
+	return pruneEmptyDirsRoot(root, false)
+}
 
-		return buf
+// PruneEmptyDirsDryRun returns the directories that PruneEmptyDirs would
+// remove without removing them. Like PruneEmptyDirs it returns
+// ErrDangerousPath if root is a dangerous path.
+func PruneEmptyDirsDryRun(root string) ([]string, error) {
+	if err := checkDangerous("prune", root, false); err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < runs; i++ {
-		if i >= runs-1 {
-			bufFn = func() []byte {
-				return make([]byte, 1024)
-			}
-		}
+	return pruneEmptyDirsRoot(root, true)
+}
 
-		if _, err := fh.Seek(0, 0); err != nil {
-			return fmt.Errorf("failed to seek to 0,0: %w", err)
-		}
+func pruneEmptyDirsRoot(root string, dryRun bool) ([]string, error) {
+	if !IsDir(root) {
+		return nil, fmt.Errorf("not a directory: %q", root)
+	}
 
-		var written int64
+	var removed []string
+	_, err := pruneEmptyDirs(root, true, dryRun, DefaultIgnorer(), &removed)
 
-		for {
-			// end of file
-			if written >= flen {
-				break
-			}
+	return removed, err
+}
 
-			buf := bufFn()
+// pruneEmptyDirs returns true if dir is empty after pruning its sub
+// directories. In dry run mode empty directories are only recorded.
+func pruneEmptyDirs(dir string, isRoot, dryRun bool, ign *Ignorer, removed *[]string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read dir %q: %w", dir, err)
+	}
 
-			n, err := fh.Write(buf[0:min(flen-written, int64(len(buf)))])
+	empty := true
+
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != ".git" && !ign.Ignore(e.Name()) {
+			subEmpty, err := pruneEmptyDirs(filepath.Join(dir, e.Name()), false, dryRun, ign, removed)
 			if err != nil {
-				if !errors.Is(err, io.EOF) {
-					return fmt.Errorf("failed to write to file: %w", err)
-				}
-				// end of file, should not happen
-				break
+				return false, err
 			}
 
-			written += int64(n)
-		}
-		// if we fail to sync the written blocks to disk it'd be pointless
-		// do any further loops
-		if err := fh.Sync(); err != nil {
-			return fmt.Errorf("failed to sync to disk: %w", err)
+			if subEmpty {
+				continue
+			}
 		}
+
+		empty = false
 	}
 
-	if err := fh.Close(); err != nil {
-		return fmt.Errorf("failed to close file after writing: %w", err)
+	if !empty || isRoot {
+		return empty, nil
 	}
 
-	if err := os.Remove(path); err != nil {
-		return fmt.Errorf("failed to remove %s: %w", path, err)
+	if !dryRun {
+		if err := os.Remove(dir); err != nil {
+			return false, fmt.Errorf("failed to remove %q: %w", dir, err)
+		}
 	}
 
-	return nil
+	*removed = append(*removed, dir)
+
+	return true, nil
 }
 
 // FileContains searches the given file for the search string and returns true
@@ -207,11 +358,3 @@ func FileContains(path, needle string) bool {
 
 	return false
 }
-
-func min(a, b int64) int64 {
-	if a < b {
-		return a
-	}
-
-	return b
-}
@@ -1,31 +1,19 @@
 package fsutil
 
 import (
-	"crypto/rand"
 	"os"
-	"os/user"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestCleanFilename(t *testing.T) {
+func TestIsDir(t *testing.T) {
 	t.Parallel()
 
-	m := map[string]string{
-		`"§$%&aÜÄ*&b%§"'Ä"c%$"'"`: "a____b______c",
-	}
-	for k, v := range m {
-		out := CleanFilename(k)
-		t.Logf("%s -> %s / %s", k, v, out)
-
-		assert.Equal(t, v, out)
-	}
-}
-
-func TestCleanPath(t *testing.T) { //nolint:paralleltest
 	tempdir, err := os.MkdirTemp("", "gopass-")
 	require.NoError(t, err)
 
@@ -33,34 +21,30 @@ func TestCleanPath(t *testing.T) { //nolint:paralleltest
 		_ = os.RemoveAll(tempdir)
 	}()
 
-	m := map[string]string{
-		".":                                 "",
-		"/home/user/../bob/.password-store": "/home/bob/.password-store",
-		"/home/user//.password-store":       "/home/user/.password-store",
-		tempdir + "/foo.gpg":                tempdir + "/foo.gpg",
-	}
+	fn := filepath.Join(tempdir, "foo")
+	assert.NoError(t, os.WriteFile(fn, []byte("bar"), 0o644))
+	assert.Equal(t, true, IsDir(tempdir))
+	assert.Equal(t, false, IsDir(fn))
+	assert.Equal(t, false, IsDir(filepath.Join(tempdir, "non-existing")))
+}
 
-	usr, err := user.Current()
-	if err == nil {
-		hd := usr.HomeDir
-		if gph := os.Getenv("GOPASS_HOMEDIR"); gph != "" {
-			hd = gph
-		}
+func TestIsFile(t *testing.T) {
+	t.Parallel()
 
-		m["~/.password-store"] = hd + "/.password-store"
-	}
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
 
-	for in, out := range m {
-		got := CleanPath(in)
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
 
-		// filepath.Abs turns /home/bob into C:\home\bob on Windows
-		absOut, err := filepath.Abs(out)
-		assert.NoError(t, err)
-		assert.Equal(t, absOut, got)
-	}
+	fn := filepath.Join(tempdir, "foo")
+	assert.NoError(t, os.WriteFile(fn, []byte("bar"), 0o644))
+	assert.Equal(t, false, IsFile(tempdir))
+	assert.Equal(t, true, IsFile(fn))
 }
 
-func TestIsDir(t *testing.T) {
+func TestIsSymlink(t *testing.T) {
 	t.Parallel()
 
 	tempdir, err := os.MkdirTemp("", "gopass-")
@@ -70,14 +54,32 @@ func TestIsDir(t *testing.T) {
 		_ = os.RemoveAll(tempdir)
 	}()
 
+	dir := filepath.Join(tempdir, "dir")
+	require.NoError(t, os.Mkdir(dir, 0o700))
 	fn := filepath.Join(tempdir, "foo")
-	assert.NoError(t, os.WriteFile(fn, []byte("bar"), 0o644))
-	assert.Equal(t, true, IsDir(tempdir))
-	assert.Equal(t, false, IsDir(fn))
-	assert.Equal(t, false, IsDir(filepath.Join(tempdir, "non-existing")))
+	require.NoError(t, os.WriteFile(fn, []byte("bar"), 0o644))
+
+	dirLink := filepath.Join(tempdir, "dir-link")
+	require.NoError(t, os.Symlink(dir, dirLink))
+	fileLink := filepath.Join(tempdir, "file-link")
+	require.NoError(t, os.Symlink(fn, fileLink))
+
+	assert.Equal(t, true, IsSymlink(dirLink))
+	assert.Equal(t, true, IsSymlink(fileLink))
+	assert.Equal(t, false, IsSymlink(dir))
+	assert.Equal(t, false, IsSymlink(fn))
+	assert.Equal(t, false, IsSymlink(filepath.Join(tempdir, "non-existing")))
+
+	assert.Equal(t, true, IsDir(dirLink))
+	assert.Equal(t, false, IsDirNoFollow(dirLink))
+	assert.Equal(t, true, IsDirNoFollow(dir))
+
+	assert.Equal(t, true, IsFile(fileLink))
+	assert.Equal(t, false, IsFileNoFollow(fileLink))
+	assert.Equal(t, true, IsFileNoFollow(fn))
 }
 
-func TestIsFile(t *testing.T) {
+func TestExists(t *testing.T) {
 	t.Parallel()
 
 	tempdir, err := os.MkdirTemp("", "gopass-")
@@ -88,12 +90,36 @@ func TestIsFile(t *testing.T) {
 	}()
 
 	fn := filepath.Join(tempdir, "foo")
-	assert.NoError(t, os.WriteFile(fn, []byte("bar"), 0o644))
-	assert.Equal(t, false, IsFile(tempdir))
-	assert.Equal(t, true, IsFile(fn))
+	require.NoError(t, os.WriteFile(fn, []byte("foo"), 0o600))
+
+	assert.Equal(t, true, Exists(fn))
+	assert.Equal(t, false, NotExist(fn))
+	assert.Equal(t, true, Exists(tempdir))
+	assert.Equal(t, false, NotExist(tempdir))
+
+	fn = filepath.Join(tempdir, "non-existing")
+	assert.Equal(t, false, Exists(fn))
+	assert.Equal(t, true, NotExist(fn))
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		return
+	}
+
+	// stat fails with a permission error, so we just don't know
+	locked := filepath.Join(tempdir, "locked")
+	require.NoError(t, os.Mkdir(locked, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(locked, "foo"), []byte("foo"), 0o600))
+	require.NoError(t, os.Chmod(locked, 0o600))
+
+	defer func() {
+		_ = os.Chmod(locked, 0o700)
+	}()
+
+	assert.Equal(t, false, Exists(filepath.Join(locked, "foo")))
+	assert.Equal(t, false, NotExist(filepath.Join(locked, "foo")))
 }
 
-func TestShred(t *testing.T) {
+func TestSameFile(t *testing.T) {
 	t.Parallel()
 
 	tempdir, err := os.MkdirTemp("", "gopass-")
@@ -103,34 +129,48 @@ func TestShred(t *testing.T) {
 		_ = os.RemoveAll(tempdir)
 	}()
 
-	fn := filepath.Join(tempdir, "file")
-	// test successful shread
-	fh, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE, 0o644)
-	assert.NoError(t, err)
+	src := filepath.Join(tempdir, "src")
+	other := filepath.Join(tempdir, "other")
+	hardlink := filepath.Join(tempdir, "hardlink")
+	require.NoError(t, os.WriteFile(src, []byte("secret"), 0o600))
+	require.NoError(t, os.WriteFile(other, []byte("secret"), 0o600))
+	require.NoError(t, os.Link(src, hardlink))
+
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{a: src, b: src, want: true},
+		{a: src, b: hardlink, want: true},
+		{a: src, b: other, want: false},
+		{a: src, b: filepath.Join(tempdir, "non-existing"), want: false},
+	} {
+		same, err := SameFile(tc.a, tc.b)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, same, tc.b)
+	}
+
+	assert.ErrorIs(t, CopyFileForce(src, hardlink), ErrSameFile)
+	assert.ErrorIs(t, MoveFile(src, hardlink), ErrSameFile)
+	assert.Equal(t, true, IsFile(src))
 
-	buf := make([]byte, 1024)
-	for i := 0; i < 10*1024; i++ {
-		_, _ = rand.Read(buf)
-		_, _ = fh.Write(buf)
+	if runtime.GOOS == "windows" {
+		return
 	}
 
-	require.NoError(t, fh.Close())
-	assert.NoError(t, Shred(fn, 8))
-	assert.Equal(t, false, IsFile(fn))
+	link := filepath.Join(tempdir, "link")
+	require.NoError(t, os.Symlink(src, link))
 
-	// test failed
-	fh, err = os.OpenFile(fn, os.O_WRONLY|os.O_CREATE, 0o400)
-	assert.NoError(t, err)
+	same, err := SameFile(src, link)
+	require.NoError(t, err)
+	assert.Equal(t, true, same)
 
-	buf = make([]byte, 1024)
-	for i := 0; i < 10*1024; i++ {
-		_, _ = rand.Read(buf)
-		_, _ = fh.Write(buf)
-	}
+	// copying onto a symlink to the source must not truncate it
+	assert.ErrorIs(t, CopyFileForce(src, link), ErrSameFile)
 
-	require.NoError(t, fh.Close())
-	assert.Error(t, Shred(fn, 8))
-	assert.Equal(t, true, IsFile(fn))
+	buf, err := os.ReadFile(src)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
 }
 
 func TestIsEmptyDir(t *testing.T) {
@@ -157,3 +197,125 @@ func TestIsEmptyDir(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, false, isEmpty)
 }
+
+func TestIsEmptyDirFunc(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	root := filepath.Join(tempdir, ".password-store")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git", "objects"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "foo"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "objects", "abc"), []byte("foo"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "foo", ".gpg-id"), []byte("foo"), 0o644))
+
+	ignoreDotfiles := func(name string) bool {
+		return strings.HasPrefix(name, ".")
+	}
+
+	isEmpty, err := IsEmptyDirFunc(root, ignoreDotfiles)
+	require.NoError(t, err)
+	assert.Equal(t, true, isEmpty)
+
+	isEmpty, err = IsEmptyDirFunc(root, func(name string) bool { return name == ".git" })
+	require.NoError(t, err)
+	assert.Equal(t, false, isEmpty)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "foo", "bar.gpg"), []byte("foo"), 0o644))
+
+	isEmpty, err = IsEmptyDirFunc(root, ignoreDotfiles)
+	require.NoError(t, err)
+	assert.Equal(t, false, isEmpty)
+
+	_, err = IsEmptyDirFunc(filepath.Join(tempdir, "non-existing"), ignoreDotfiles)
+	assert.Error(t, err)
+}
+
+func TestIsEmptyDirWithOpts(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	outside := filepath.Join(tempdir, "outside")
+	require.NoError(t, os.WriteFile(outside, []byte("foo"), 0o600))
+
+	root := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "foo"), 0o700))
+	require.NoError(t, os.Symlink(filepath.Join(tempdir, "non-existing"), filepath.Join(root, "foo", "broken")))
+
+	isEmpty, err := IsEmptyDir(root)
+	require.NoError(t, err)
+	assert.Equal(t, false, isEmpty)
+
+	opts := IsEmptyDirOpts{IgnoreBrokenSymlinks: true}
+
+	isEmpty, err = IsEmptyDirWithOpts(root, opts)
+	require.NoError(t, err)
+	assert.Equal(t, true, isEmpty)
+
+	// valid links still count
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "foo", "link")))
+
+	isEmpty, err = IsEmptyDirWithOpts(root, opts)
+	require.NoError(t, err)
+	assert.Equal(t, false, isEmpty)
+
+	opts.Ignore = func(name string) bool { return name == "link" }
+
+	isEmpty, err = IsEmptyDirWithOpts(root, opts)
+	require.NoError(t, err)
+	assert.Equal(t, true, isEmpty)
+}
+
+func TestPruneEmptyDirs(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "a", "b", "c"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "d", "e"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, ".git", "refs", "tags"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "d", "foo.gpg"), []byte("foo"), 0o644))
+
+	dry, err := PruneEmptyDirsDryRun(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, true, IsDir(filepath.Join(tempdir, "a", "b", "c")))
+	assert.Equal(t, true, IsDir(filepath.Join(tempdir, "d", "e")))
+
+	removed, err := PruneEmptyDirs(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, dry, removed)
+	assert.Equal(t, []string{
+		filepath.Join(tempdir, "a", "b", "c"),
+		filepath.Join(tempdir, "a", "b"),
+		filepath.Join(tempdir, "a"),
+		filepath.Join(tempdir, "d", "e"),
+	}, removed)
+
+	assert.Equal(t, true, IsDir(tempdir))
+	assert.Equal(t, true, IsDir(filepath.Join(tempdir, "d")))
+	assert.Equal(t, true, IsDir(filepath.Join(tempdir, ".git", "refs", "tags")))
+
+	// calling it again is a no-op
+	removed, err = PruneEmptyDirs(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, removed, 0)
+
+	_, err = PruneEmptyDirs(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
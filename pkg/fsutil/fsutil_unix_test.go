@@ -0,0 +1,64 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package fsutil
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSpecial(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fifo := filepath.Join(tempdir, "fifo")
+	require.NoError(t, syscall.Mkfifo(fifo, 0o600))
+
+	assert.Equal(t, true, IsFifo(fifo))
+	assert.Equal(t, false, IsFile(fifo))
+	assert.Equal(t, false, IsRegular(fifo))
+	assert.Equal(t, false, IsSocket(fifo))
+	assert.Equal(t, false, IsDevice(fifo))
+
+	sock := filepath.Join(tempdir, "sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+
+	defer func() {
+		_ = l.Close()
+	}()
+
+	assert.Equal(t, true, IsSocket(sock))
+	assert.Equal(t, false, IsFifo(sock))
+	assert.Equal(t, false, IsRegular(sock))
+
+	assert.Equal(t, true, IsDevice("/dev/null"))
+	assert.Equal(t, false, IsRegular("/dev/null"))
+
+	file := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(file, []byte("foo"), 0o600))
+	assert.Equal(t, true, IsRegular(file))
+	assert.Equal(t, false, IsFifo(file))
+	assert.Equal(t, false, IsSocket(file))
+	assert.Equal(t, false, IsDevice(file))
+
+	link := filepath.Join(tempdir, "link")
+	require.NoError(t, os.Symlink(file, link))
+	assert.Equal(t, false, IsRegular(link))
+
+	assert.Equal(t, false, IsRegular(filepath.Join(tempdir, "non-existing")))
+	assert.Equal(t, false, IsFifo(filepath.Join(tempdir, "non-existing")))
+}
@@ -0,0 +1,63 @@
+package fsutil
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Glob returns all files below root whose slash separated path relative to
+// root matches pattern. Besides the syntax supported by path.Match, a path
+// element consisting only of "**" matches zero or more path elements.
+// Hidden entries and .git are skipped, like in ListFiles. If nothing matches
+// an empty slice is returned. On Windows backslashes in pattern are treated
+// as separators, elsewhere they escape the following character.
+func Glob(root, pattern string) ([]string, error) {
+	pat := strings.Split(ToSlash(pattern), "/")
+	for _, p := range pat {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	files, err := ListFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []string{}
+
+	for _, f := range files {
+		if globMatch(pat, strings.Split(f, "/")) {
+			out = append(out, f)
+		}
+	}
+
+	return out, nil
+}
+
+func globMatch(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if globMatch(pat[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) < 1 {
+			return false
+		}
+
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+
+		pat, name = pat[1:], name[1:]
+	}
+
+	return len(name) < 1
+}
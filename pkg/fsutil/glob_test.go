@@ -0,0 +1,75 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	for _, fn := range []string{
+		"aws/prod/key.gpg",
+		"aws/dev/key.gpg",
+		"aws/dev/eu/key.gpg",
+		"aws/root.gpg",
+		"gcp/key.gpg",
+		".hidden/key.gpg",
+	} {
+		fp := filepath.Join(tempdir, filepath.FromSlash(fn))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0o700))
+		require.NoError(t, os.WriteFile(fp, []byte(fn), 0o600))
+	}
+
+	for _, tc := range []struct {
+		pattern string
+		want    []string
+	}{
+		{
+			pattern: "aws/*/key.gpg",
+			want:    []string{"aws/dev/key.gpg", "aws/prod/key.gpg"},
+		},
+		{
+			pattern: "aws/???/key.gpg",
+			want:    []string{"aws/dev/key.gpg"},
+		},
+		{
+			pattern: "aws/**/key.gpg",
+			want:    []string{"aws/dev/eu/key.gpg", "aws/dev/key.gpg", "aws/prod/key.gpg"},
+		},
+		{
+			pattern: "**/key.gpg",
+			want:    []string{"aws/dev/eu/key.gpg", "aws/dev/key.gpg", "aws/prod/key.gpg", "gcp/key.gpg"},
+		},
+		{
+			pattern: "aws/**",
+			want:    []string{"aws/dev/eu/key.gpg", "aws/dev/key.gpg", "aws/prod/key.gpg", "aws/root.gpg"},
+		},
+		{
+			pattern: "*.gpg",
+			want:    []string{},
+		},
+		{
+			pattern: "azure/**",
+			want:    []string{},
+		},
+	} {
+		got, err := Glob(tempdir, tc.pattern)
+		require.NoError(t, err, tc.pattern)
+		assert.Equal(t, tc.want, got, tc.pattern)
+	}
+
+	_, err = Glob(tempdir, "aws/[")
+	assert.Error(t, err)
+}
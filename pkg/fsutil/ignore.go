@@ -0,0 +1,62 @@
+package fsutil
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// IgnorePatterns are the default patterns of entries ignored by Walk,
+// ListFiles, IsEmptyDir and PruneEmptyDirs, in addition to what these skip
+// anyway. The patterns are matched against the base name of each entry
+// using filepath.Match, e.g. "*.swp" or ".DS_Store". Invalid patterns never
+// match. This should only be changed during initialization, it's not safe
+// for concurrent use.
+var IgnorePatterns = []string{}
+
+// Ignorer decides which entries to ignore based on a list of patterns.
+// A nil Ignorer ignores nothing.
+type Ignorer struct {
+	patterns []string
+}
+
+// NewIgnorer returns an Ignorer for the given patterns. See IgnorePatterns
+// for the syntax. It fails if any of the patterns is invalid.
+func NewIgnorer(patterns ...string) (*Ignorer, error) {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+
+	return &Ignorer{patterns: append([]string{}, patterns...)}, nil
+}
+
+// DefaultIgnorer returns an Ignorer for the current IgnorePatterns.
+func DefaultIgnorer() *Ignorer {
+	return &Ignorer{patterns: IgnorePatterns}
+}
+
+// Ignore returns true if name matches any of the patterns. name should be
+// a base name, not a path.
+func (i *Ignorer) Ignore(name string) bool {
+	if i == nil {
+		return false
+	}
+
+	for _, p := range i.patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// orDefault returns i or the default Ignorer if i is nil.
+func (i *Ignorer) orDefault() *Ignorer {
+	if i != nil {
+		return i
+	}
+
+	return DefaultIgnorer()
+}
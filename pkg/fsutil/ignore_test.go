@@ -0,0 +1,109 @@
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIgnorer(t *testing.T) {
+	t.Parallel()
+
+	ign, err := NewIgnorer("*.swp", ".DS_Store", "~*")
+	require.NoError(t, err)
+
+	for name, want := range map[string]bool{
+		"foo.gpg.swp": true,
+		".DS_Store":   true,
+		"~foo":        true,
+		"foo.gpg":     false,
+		"foo.swp.gpg": false,
+		"DS_Store":    false,
+	} {
+		assert.Equal(t, want, ign.Ignore(name), name)
+	}
+
+	var nilIgnorer *Ignorer
+	assert.Equal(t, false, nilIgnorer.Ignore("foo.swp"))
+
+	_, err = NewIgnorer("[")
+	assert.Error(t, err)
+}
+
+func TestIgnorePatterns(t *testing.T) { //nolint:paralleltest
+	old := IgnorePatterns
+	IgnorePatterns = append([]string{}, "*.swp")
+
+	defer func() {
+		IgnorePatterns = old
+	}()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	for _, fn := range []string{
+		"foo.gpg",
+		"foo.gpg.swp",
+		"sub/bar.gpg",
+		"sub/bar.gpg.swp",
+		"swap/baz.gpg.swp",
+	} {
+		fp := filepath.Join(tempdir, filepath.FromSlash(fn))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0o700))
+		require.NoError(t, os.WriteFile(fp, []byte(fn), 0o600))
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "dir.swp", "empty"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "empty"), 0o700))
+
+	var walked []string
+	require.NoError(t, Walk(tempdir, WalkOpts{}, func(path string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			walked = append(walked, path)
+		}
+
+		return nil
+	}))
+	assert.Equal(t, []string{
+		filepath.Join(tempdir, "foo.gpg"),
+		filepath.Join(tempdir, "sub", "bar.gpg"),
+	}, walked)
+
+	// an explicit Ignorer replaces the defaults
+	walked = nil
+	require.NoError(t, Walk(tempdir, WalkOpts{Ignorer: &Ignorer{}}, func(path string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			walked = append(walked, path)
+		}
+
+		return nil
+	}))
+	assert.Len(t, walked, 5)
+
+	files, err := ListFiles(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo.gpg", "sub/bar.gpg"}, files)
+
+	dirs, err := ListDirs(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"empty", "sub", "swap"}, dirs)
+
+	empty, err := IsEmptyDir(filepath.Join(tempdir, "swap"))
+	require.NoError(t, err)
+	assert.Equal(t, true, empty)
+
+	// ignored entries are left alone
+	removed, err := PruneEmptyDirs(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(tempdir, "empty")}, removed)
+	assert.Equal(t, true, IsDir(filepath.Join(tempdir, "dir.swp", "empty")))
+	assert.Equal(t, true, IsFile(filepath.Join(tempdir, "swap", "baz.gpg.swp")))
+}
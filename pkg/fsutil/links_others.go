@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !solaris && !aix && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!solaris,!aix,!windows
+
+package fsutil
+
+// LinkCount is not supported on this platform.
+func LinkCount(path string) (uint64, error) {
+	return 0, ErrNotSupported
+}
@@ -0,0 +1,50 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkCount(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret")
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+
+	n, err := LinkCount(fn)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), n)
+
+	link := filepath.Join(tempdir, "link")
+	require.NoError(t, os.Link(fn, link))
+
+	n, err = LinkCount(fn)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+
+	_, err = LinkCount(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+
+	// the file is left alone
+	err = ShredWithOpts(fn, ShredOpts{Rounds: 1, FailHardLinks: true})
+	assert.ErrorIs(t, err, ErrMultipleHardLinks)
+
+	buf, err := os.ReadFile(link)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(buf))
+
+	require.NoError(t, os.Remove(link))
+	assert.NoError(t, ShredWithOpts(fn, ShredOpts{Rounds: 1, FailHardLinks: true}))
+	assert.Equal(t, false, IsFile(fn))
+}
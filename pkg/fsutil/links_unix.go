@@ -0,0 +1,26 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || aix
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris aix
+
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// LinkCount returns the number of hard links to the file at path. Symlinks
+// are not followed.
+func LinkCount(path string) (uint64, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, ErrNotSupported
+	}
+
+	return uint64(st.Nlink), nil //nolint:unconvert
+}
@@ -0,0 +1,30 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// LinkCount returns the number of hard links to the file at path.
+func LinkCount(path string) (uint64, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	var info windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(windows.Handle(fh.Fd()), &info); err != nil {
+		return 0, fmt.Errorf("failed to get file information of %q: %w", path, err)
+	}
+
+	return uint64(info.NumberOfLinks), nil
+}
@@ -0,0 +1,174 @@
+package fsutil
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ListFiles returns all regular files below root as slash separated paths
+// relative to root, sorted lexically. Hidden entries and .git are skipped.
+func ListFiles(root string) ([]string, error) {
+	return list(root, func(d fs.DirEntry) bool {
+		return d.Type().IsRegular()
+	})
+}
+
+// ListDirs is like ListFiles but returns the directories below root,
+// excluding root itself.
+func ListDirs(root string) ([]string, error) {
+	return list(root, func(d fs.DirEntry) bool {
+		return d.IsDir()
+	})
+}
+
+// FindOrphans returns the files below root that do not end with one of the
+// given extensions, e.g. backup files left by an editor in a store that
+// should only contain .gpg files. Like in ListFiles hidden entries and .git
+// are skipped and the paths are slash separated and relative to root.
+func FindOrphans(root string, extensions []string) ([]string, error) {
+	files, err := ListFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	exts := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		exts = append(exts, ext)
+	}
+
+	orphans := []string{}
+
+	for _, fn := range files {
+		if !hasAnySuffix(fn, exts) {
+			orphans = append(orphans, fn)
+		}
+	}
+
+	return orphans, nil
+}
+
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CountByDir returns the number of regular files directly inside each
+// directory below and including root, keyed by the slash separated path
+// relative to root. root itself is ".". Directories without any files are
+// included with a count of zero. Like in ListFiles hidden entries and .git
+// are skipped.
+func CountByDir(root string) (map[string]int, error) {
+	counts := map[string]int{}
+
+	err := Walk(root, WalkOpts{SkipHidden: true, SkipGit: true}, func(path string, d fs.DirEntry) error {
+		dir := path
+		if !d.IsDir() {
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			dir = filepath.Dir(path)
+		}
+
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		// directories are visited before their content
+		if d.IsDir() {
+			counts[ToSlash(rel)] = 0
+
+			return nil
+		}
+
+		counts[ToSlash(rel)]++
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func list(root string, want func(fs.DirEntry) bool) ([]string, error) {
+	out := []string{}
+
+	err := Walk(root, WalkOpts{SkipHidden: true, SkipGit: true}, func(path string, d fs.DirEntry) error {
+		if path == root || !want(d) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		out = append(out, ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(out)
+
+	return out, nil
+}
+
+// FindCaseCollisions returns all entries below root whose paths only differ
+// by case, e.g. Github.gpg and github.gpg. Those work fine on Linux but
+// overwrite each other on case-insensitive filesystems like on macOS or
+// Windows. The result maps the lower case path to all paths that share it,
+// sorted lexically. Paths are slash separated and relative to root. Only
+// .git is skipped since hidden files like .gpg-id collide as well.
+func FindCaseCollisions(root string) (map[string][]string, error) {
+	groups := map[string][]string{}
+
+	err := Walk(root, WalkOpts{SkipGit: true}, func(path string, d fs.DirEntry) error {
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		rel = ToSlash(rel)
+		key := strings.ToLower(rel)
+		groups[key] = append(groups[key], rel)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	collisions := map[string][]string{}
+
+	for key, paths := range groups {
+		if len(paths) < 2 {
+			continue
+		}
+
+		sort.Strings(paths)
+		collisions[key] = paths
+	}
+
+	return collisions, nil
+}
@@ -0,0 +1,165 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFiles(t *testing.T) {
+	t.Parallel()
+
+	root := walkFixture(t)
+
+	defer func() {
+		_ = os.RemoveAll(root)
+	}()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "foo-bar"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "foo-bar", "a.gpg"), []byte("a"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "empty"), 0o700))
+
+	files, err := ListFiles(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"foo-bar/a.gpg",
+		"foo/README.md",
+		"foo/bar/baz.gpg",
+		"foo/zab.gpg",
+		"top.gpg",
+	}, files)
+
+	dirs, err := ListDirs(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"empty",
+		"foo",
+		"foo-bar",
+		"foo/bar",
+	}, dirs)
+
+	files, err = ListFiles(filepath.Join(root, "empty"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, files)
+
+	_, err = ListFiles(filepath.Join(root, "non-existing"))
+	assert.Error(t, err)
+}
+
+func TestFindOrphans(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	for _, fn := range []string{
+		".gpg-id",
+		".git/config",
+		"foo/bar.gpg",
+		"foo/bar.gpg.bak",
+		"foo/.bar.gpg.swp",
+		"notes.txt",
+		"baz.age",
+		"gpg",
+		"top.gpg",
+	} {
+		fp := filepath.Join(tempdir, filepath.FromSlash(fn))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0o700))
+		require.NoError(t, os.WriteFile(fp, []byte(fn), 0o600))
+	}
+
+	orphans, err := FindOrphans(tempdir, []string{".gpg", "age"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/bar.gpg.bak", "gpg", "notes.txt"}, orphans)
+
+	orphans, err = FindOrphans(tempdir, []string{".gpg", ".age", ".bak", ".txt"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpg"}, orphans)
+
+	orphans, err = FindOrphans(tempdir, []string{".gpg", ".age", ".bak", ".txt", "gpg"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpg"}, orphans)
+
+	_, err = FindOrphans(filepath.Join(tempdir, "non-existing"), []string{".gpg"})
+	assert.Error(t, err)
+}
+
+func TestCountByDir(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	for _, fn := range []string{
+		".gpg-id",
+		".git/config",
+		"foo.gpg",
+		"bar.gpg",
+		"web/example.com.gpg",
+		"web/example.org.gpg",
+		"web/mail/user.gpg",
+		"web/mail/.gpg-id",
+		"deep/nested/dir/a.gpg",
+		".hidden/secret.gpg",
+	} {
+		fp := filepath.Join(tempdir, filepath.FromSlash(fn))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0o700))
+		require.NoError(t, os.WriteFile(fp, []byte(fn), 0o600))
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "empty"), 0o700))
+
+	counts, err := CountByDir(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		".":               2,
+		"deep":            0,
+		"deep/nested":     0,
+		"deep/nested/dir": 1,
+		"empty":           0,
+		"web":             2,
+		"web/mail":        1,
+	}, counts)
+
+	_, err = CountByDir(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
+
+func TestFindCaseCollisions(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "web"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "web", "Github.gpg"), []byte("foo"), 0o600))
+
+	if IsFile(filepath.Join(tempdir, "web", "github.gpg")) {
+		t.Skip("filesystem is case-insensitive")
+	}
+
+	for _, fn := range []string{"web/github.gpg", "web/gitlab.gpg", "mail.gpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempdir, filepath.FromSlash(fn)), []byte("foo"), 0o600))
+	}
+
+	collisions, err := FindCaseCollisions(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"web/github.gpg": {"web/Github.gpg", "web/github.gpg"},
+	}, collisions)
+}
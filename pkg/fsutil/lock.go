@@ -0,0 +1,151 @@
+package fsutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// lockRetryInterval is the time between two attempts to acquire a lock.
+var lockRetryInterval = 50 * time.Millisecond
+
+// FileLock is an exclusive, advisory lock shared between processes. It is
+// backed by a sidecar file next to the protected path. The sidecar file is
+// removed again on Unlock, so no stray files are left in the store. A
+// process that locked the file just before it was removed notices that it
+// holds a lock on a file that no longer exists and tries again with a new
+// one, so two processes never hold the lock at the same time.
+type FileLock struct {
+	path string
+
+	mu sync.Mutex
+	fh *os.File
+}
+
+// NewLock returns a new, unlocked FileLock protecting path. The lock file is
+// path with a .lock suffix, it only exists while the lock is held. Locks on
+// network filesystems might not be reliable, a warning is logged in that
+// case.
+func NewLock(path string) *FileLock {
+	if remote, err := IsNetworkFS(filepath.Dir(path)); err == nil && remote {
+		debug.Log("WARNING: %s is on a network filesystem, locking might not work reliably", path)
+	}
+
+	return &FileLock{
+		path: path + ".lock",
+	}
+}
+
+// TryLock attempts to acquire the lock without blocking. It returns false if
+// the lock is held by someone else.
+func (l *FileLock) TryLock() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.fh != nil {
+		return true, nil
+	}
+
+	for {
+		fh, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o600)
+		if err != nil {
+			return false, fmt.Errorf("failed to open lock file %q: %w", l.path, err)
+		}
+
+		ok, err := tryLockFile(fh)
+		if err != nil || !ok {
+			_ = fh.Close()
+
+			return false, err
+		}
+
+		if isLockFile(fh, l.path) {
+			l.fh = fh
+
+			return true, nil
+		}
+
+		// the previous owner removed the file before we got the lock
+		_ = unlockFile(fh)
+		_ = fh.Close()
+	}
+}
+
+// isLockFile returns true if fh is still the file at path.
+func isLockFile(fh *os.File, path string) bool {
+	fi, err := fh.Stat()
+	if err != nil {
+		return false
+	}
+
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(fi, pi)
+}
+
+// Lock blocks until the lock is acquired or the context is canceled.
+func (l *FileLock) Lock(ctx context.Context) error {
+	for {
+		ok, err := l.TryLock()
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to acquire lock %q: %w", l.path, ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Unlock releases the lock and removes the lock file. Unlocking a lock that
+// is not held is a no-op.
+func (l *FileLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.fh == nil {
+		return nil
+	}
+
+	fh := l.fh
+	l.fh = nil
+
+	// Windows does not remove open files, the file is removed after closing
+	// it instead. That fails if someone else opened it in the meantime.
+	if runtime.GOOS != "windows" {
+		if err := os.Remove(l.path); err != nil {
+			debug.Log("failed to remove lock file %s: %s", l.path, err)
+		}
+	}
+
+	if err := unlockFile(fh); err != nil {
+		_ = fh.Close()
+
+		return fmt.Errorf("failed to unlock %q: %w", l.path, err)
+	}
+
+	if err := fh.Close(); err != nil {
+		return fmt.Errorf("failed to close lock file %q: %w", l.path, err)
+	}
+
+	if runtime.GOOS == "windows" {
+		_ = os.Remove(l.path)
+	}
+
+	return nil
+}
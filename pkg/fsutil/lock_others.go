@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !solaris && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!solaris,!windows
+
+package fsutil
+
+import (
+	"fmt"
+	"os"
+)
+
+func tryLockFile(fh *os.File) (bool, error) {
+	return false, fmt.Errorf("can not lock %q: %w", fh.Name(), ErrNotSupported)
+}
+
+func unlockFile(fh *os.File) error {
+	return ErrNotSupported
+}
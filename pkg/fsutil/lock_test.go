@@ -0,0 +1,113 @@
+package fsutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLock(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret.gpg")
+
+	l1 := NewLock(fn)
+	l2 := NewLock(fn)
+
+	locked := make(chan struct{})
+	unlock := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		assert.NoError(t, l1.Lock(context.Background()))
+		close(locked)
+		<-unlock
+		assert.NoError(t, l1.Unlock())
+	}()
+
+	<-locked
+
+	ok, err := l2.TryLock()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l2.Lock(ctx), context.DeadlineExceeded)
+
+	close(unlock)
+	<-done
+
+	ok, err = l2.TryLock()
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	assert.NoError(t, l2.Unlock())
+	assert.NoError(t, l2.Unlock())
+	assert.NoError(t, l1.Unlock())
+}
+
+func TestFileLockRemove(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret.gpg")
+
+	l := NewLock(fn)
+	require.NoError(t, l.Lock(context.Background()))
+	assert.Equal(t, true, IsFile(fn+".lock"))
+	require.NoError(t, l.Unlock())
+	assert.Equal(t, false, Exists(fn+".lock"))
+
+	// removing the lock file must never let two owners in at once
+	var wg sync.WaitGroup
+
+	var holders int32
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			l := NewLock(fn)
+			for j := 0; j < 20; j++ {
+				if !assert.NoError(t, l.Lock(context.Background())) {
+					return
+				}
+
+				assert.Equal(t, int32(1), atomic.AddInt32(&holders, 1))
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+
+				assert.NoError(t, l.Unlock())
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, false, Exists(fn+".lock"))
+}
@@ -0,0 +1,28 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris
+
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func tryLockFile(fh *os.File) (bool, error) {
+	if err := unix.Flock(int(fh.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		if errors.Is(err, unix.EWOULDBLOCK) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to lock %q: %w", fh.Name(), err)
+	}
+
+	return true, nil
+}
+
+func unlockFile(fh *os.File) error {
+	return unix.Flock(int(fh.Fd()), unix.LOCK_UN) //nolint:wrapcheck
+}
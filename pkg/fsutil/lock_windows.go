@@ -0,0 +1,35 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func tryLockFile(fh *os.File) (bool, error) {
+	ol := new(windows.Overlapped)
+	if err := windows.LockFileEx(
+		windows.Handle(fh.Fd()),
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+		0, 1, 0, ol,
+	); err != nil {
+		if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to lock %q: %w", fh.Name(), err)
+	}
+
+	return true, nil
+}
+
+func unlockFile(fh *os.File) error {
+	ol := new(windows.Overlapped)
+
+	return windows.UnlockFileEx(windows.Handle(fh.Fd()), 0, 1, 0, ol) //nolint:wrapcheck
+}
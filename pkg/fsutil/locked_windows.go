@@ -0,0 +1,35 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// IsFileLocked reports whether the file at path is opened by another process
+// in a way that prevents us from writing to it, e.g. by an editor or a virus
+// scanner. It tries to open the file without sharing it and closes it right
+// away, the file is never modified.
+func IsFileLocked(path string) (bool, error) {
+	p, err := windows.UTF16PtrFromString(fixLongPath(path))
+	if err != nil {
+		return false, fmt.Errorf("invalid path %q: %w", path, err)
+	}
+
+	h, err := windows.CreateFile(p, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+			return true, nil
+		}
+
+		return false, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	_ = windows.CloseHandle(h)
+
+	return false, nil
+}
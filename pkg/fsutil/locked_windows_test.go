@@ -0,0 +1,53 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows"
+)
+
+func TestIsFileLocked(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+
+	locked, err := IsFileLocked(fn)
+	require.NoError(t, err)
+	assert.Equal(t, false, locked)
+
+	p, err := windows.UTF16PtrFromString(fn)
+	require.NoError(t, err)
+
+	h, err := windows.CreateFile(p, windows.GENERIC_READ, 0, nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	require.NoError(t, err)
+
+	locked, err = IsFileLocked(fn)
+	require.NoError(t, err)
+	assert.Equal(t, true, locked)
+
+	assert.ErrorIs(t, Shred(fn, 1), ErrFileInUse)
+
+	require.NoError(t, windows.CloseHandle(h))
+
+	locked, err = IsFileLocked(fn)
+	require.NoError(t, err)
+	assert.Equal(t, false, locked)
+
+	_, err = IsFileLocked(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package fsutil
+
+// fixLongPath is a no-op, only Windows limits the length of paths.
+func fixLongPath(path string) string {
+	return path
+}
@@ -0,0 +1,41 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxShortPath is the longest path that works without the \\?\ prefix.
+// Directories are limited to MAX_PATH (260) minus a 8.3 filename.
+const maxShortPath = 248
+
+// fixLongPath returns the extended-length form of the absolute path if it
+// exceeds MAX_PATH. Such paths are passed to the Windows API as is, so they
+// are cleaned first. Shorter and relative paths are returned unchanged.
+// Only use it right before accessing the filesystem, never for paths
+// returned to the caller, see CleanPath.
+func fixLongPath(path string) string {
+	if len(path) < maxShortPath {
+		return path
+	}
+
+	if strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+
+	if !filepath.IsAbs(path) {
+		return path
+	}
+
+	path = filepath.Clean(path)
+
+	// \\server\share\foo -> \\?\UNC\server\share\foo
+	if strings.HasPrefix(path, `\\`) {
+		return `\\?\UNC\` + path[2:]
+	}
+
+	return `\\?\` + path
+}
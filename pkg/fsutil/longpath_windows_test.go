@@ -0,0 +1,63 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixLongPath(t *testing.T) {
+	t.Parallel()
+
+	long := `C:\` + strings.Repeat(`a\`, 150) + "secret.gpg"
+
+	for in, want := range map[string]string{
+		`C:\foo\bar`:                       `C:\foo\bar`,
+		long:                               `\\?\` + long,
+		strings.ReplaceAll(long, `\`, "/"): `\\?\` + long,
+		`\\?\` + long:                      `\\?\` + long,
+		`\\server\share\` + long[3:]:       `\\?\UNC\server\share\` + long[3:],
+		strings.Repeat(`a\`, 150):          strings.Repeat(`a\`, 150),
+	} {
+		assert.Equal(t, want, fixLongPath(in), in)
+	}
+}
+
+func TestLongPath(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	dir := tempdir
+	for len(dir) < 300 {
+		dir = filepath.Join(dir, "deeply-nested-folder")
+	}
+
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	assert.Equal(t, true, IsDir(dir))
+
+	src := filepath.Join(dir, "secret.gpg")
+	require.NoError(t, os.WriteFile(src, []byte("foo"), 0o600))
+	assert.Equal(t, true, IsFile(src))
+
+	dst := filepath.Join(dir, "copy.gpg")
+	require.NoError(t, CopyFile(src, dst))
+	assert.Equal(t, true, IsFile(dst))
+
+	moved := filepath.Join(dir, "moved.gpg")
+	require.NoError(t, MoveFile(dst, moved))
+	assert.Equal(t, true, IsFile(moved))
+	assert.Equal(t, false, IsFile(dst))
+}
@@ -0,0 +1,112 @@
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MemFS is an in-memory implementation of FS. Directories are implicit,
+// i.e. a directory exists as long as there is a file below it. It's safe
+// for concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string]*memFile
+}
+
+type memFile struct {
+	data []byte
+	mode os.FileMode
+}
+
+// NewMemFS returns a new, empty MemFS.
+func NewMemFS() *MemFS {
+	return &MemFS{
+		files: map[string]*memFile{},
+	}
+}
+
+// CleanPath works like CleanPath.
+func (m *MemFS) CleanPath(path string) string {
+	return CleanPath(path)
+}
+
+// IsDir returns true if there is at least one file below path.
+func (m *MemFS) IsDir(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefix := m.CleanPath(path)
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+
+	for fn := range m.files {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsFile returns true if a file exists at path.
+func (m *MemFS) IsFile(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, found := m.files[m.CleanPath(path)]
+
+	return found
+}
+
+// ReadFile returns a copy of the content of the file at path.
+func (m *MemFS) ReadFile(path string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, found := m.files[m.CleanPath(path)]
+	if !found {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+
+	return append([]byte{}, f.data...), nil
+}
+
+// WriteFileAtomic stores a copy of data at path, replacing any existing
+// file.
+func (m *MemFS) WriteFileAtomic(path string, data []byte, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[m.CleanPath(path)] = &memFile{
+		data: append([]byte{}, data...),
+		mode: mode,
+	}
+
+	return nil
+}
+
+// Shred zeroes the content of the file at path and removes it. runs is
+// ignored.
+func (m *MemFS) Shred(path string, runs int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fn := m.CleanPath(path)
+
+	f, found := m.files[fn]
+	if !found {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+
+	for i := range f.data {
+		f.data[i] = 0
+	}
+
+	delete(m.files, fn)
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	for name, fsys := range map[string]FS{
+		"os":  Default,
+		"mem": NewMemFS(),
+	} {
+		dir := filepath.Join(tempdir, name)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o700))
+
+		fn := filepath.Join(dir, "sub", "secret.gpg")
+		assert.Equal(t, false, fsys.IsFile(fn), name)
+
+		require.NoError(t, fsys.WriteFileAtomic(fn, []byte("foobar"), 0o600), name)
+		assert.Equal(t, true, fsys.IsFile(fn), name)
+		assert.Equal(t, false, fsys.IsDir(fn), name)
+		assert.Equal(t, true, fsys.IsDir(filepath.Join(dir, "sub")), name)
+		assert.Equal(t, false, fsys.IsFile(filepath.Join(dir, "sub")), name)
+		assert.Equal(t, fn, fsys.CleanPath(filepath.Join(dir, "sub", "..", "sub", "secret.gpg")), name)
+
+		buf, err := fsys.ReadFile(fn)
+		require.NoError(t, err, name)
+		assert.Equal(t, "foobar", string(buf), name)
+
+		require.NoError(t, fsys.Shred(fn, 1), name)
+		assert.Equal(t, false, fsys.IsFile(fn), name)
+
+		_, err = fsys.ReadFile(fn)
+		assert.ErrorIs(t, err, fs.ErrNotExist, name)
+		assert.ErrorIs(t, fsys.Shred(fn, 1), fs.ErrNotExist, name)
+	}
+}
+
+func TestMemFSShred(t *testing.T) {
+	t.Parallel()
+
+	m := NewMemFS()
+	require.NoError(t, m.WriteFileAtomic("/foo", []byte("foobar"), 0o600))
+
+	// keep a reference to the internal buffer to check it's zeroed
+	buf := m.files[m.CleanPath("/foo")].data
+
+	require.NoError(t, m.Shred("/foo", 1))
+	assert.Equal(t, make([]byte, 6), buf)
+	assert.Equal(t, false, m.IsFile("/foo"))
+	assert.Equal(t, false, m.IsDir("/"))
+}
@@ -0,0 +1,87 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Mmap is a read-only memory mapping of a file.
+type Mmap struct {
+	mu     sync.Mutex
+	data   []byte
+	closed bool
+}
+
+// OpenMmap maps the file at path into memory. The mapping reflects changes
+// to the file made after it has been opened, e.g. by other processes, and
+// accessing it after the file has been truncated may crash the program.
+// On Windows and platforms without mmap the file is read into memory
+// instead.
+func OpenMmap(path string) (*Mmap, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file: %q", path)
+	}
+
+	m := &Mmap{}
+
+	// mapping an empty file fails
+	if fi.Size() < 1 {
+		return m, nil
+	}
+
+	m.data, err = mmap(fh, int(fi.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to map %q: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Bytes returns the content of the mapped file. The slice must not be
+// modified and must not be used after Close.
+func (m *Mmap) Bytes() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.data
+}
+
+// Close releases the mapping. It is safe to call it more than once.
+func (m *Mmap) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil
+	}
+
+	m.closed = true
+
+	data := m.data
+	m.data = nil
+
+	if data == nil {
+		return nil
+	}
+
+	if err := munmap(data); err != nil {
+		return fmt.Errorf("failed to unmap: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !solaris && !aix
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!solaris,!aix
+
+package fsutil
+
+import (
+	"io"
+	"os"
+)
+
+// mmap reads the file into memory on Windows and on platforms without
+// mmap.
+func mmap(fh *os.File, size int) ([]byte, error) {
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(fh, buf); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return buf, nil
+}
+
+func munmap(data []byte) error {
+	for i := range data {
+		data[i] = 0
+	}
+
+	return nil
+}
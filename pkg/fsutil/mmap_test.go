@@ -0,0 +1,45 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenMmap(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte("foobar"), 0o600))
+
+	m, err := OpenMmap(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", string(m.Bytes()))
+	assert.NoError(t, m.Close())
+	assert.NoError(t, m.Close())
+	assert.Nil(t, m.Bytes())
+
+	empty := filepath.Join(tempdir, "empty")
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+
+	m, err = OpenMmap(empty)
+	require.NoError(t, err)
+	assert.Len(t, m.Bytes(), 0)
+	assert.NoError(t, m.Close())
+
+	_, err = OpenMmap(tempdir)
+	assert.Error(t, err)
+
+	_, err = OpenMmap(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
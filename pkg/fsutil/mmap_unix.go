@@ -0,0 +1,18 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || aix
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris aix
+
+package fsutil
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func mmap(fh *os.File, size int) ([]byte, error) {
+	return unix.Mmap(int(fh.Fd()), 0, size, unix.PROT_READ, unix.MAP_SHARED) //nolint:wrapcheck
+}
+
+func munmap(data []byte) error {
+	return unix.Munmap(data) //nolint:wrapcheck
+}
@@ -0,0 +1,42 @@
+package fsutil
+
+import "strings"
+
+// SplitMount returns the longest of the given mount points that contains path
+// and the remainder of path below it. Mounts and path are store paths,
+// separated by forward slashes (on Windows backslashes are converted as
+// well), and the returned sub path always uses forward slashes. A mount only
+// matches at a path separator, so "team" does not contain "team-secret/foo".
+// The empty mount matches every path. ok is false if no mount matches.
+func SplitMount(mounts []string, path string) (mount, sub string, ok bool) {
+	p := normalizeStorePath(path)
+	best := ""
+
+	for _, m := range mounts {
+		nm := normalizeStorePath(m)
+		if !storePathContains(nm, p) || (ok && len(nm) <= len(best)) {
+			continue
+		}
+
+		mount, best, ok = m, nm, true
+	}
+
+	if !ok {
+		return "", "", false
+	}
+
+	return mount, strings.TrimPrefix(strings.TrimPrefix(p, best), "/"), true
+}
+
+// storePathContains returns true if the normalized store path p is mount or
+// below mount.
+func storePathContains(mount, p string) bool {
+	return mount == "" || p == mount || strings.HasPrefix(p, mount+"/")
+}
+
+// normalizeStorePath converts path to forward slashes with ToSlash and
+// removes leading and trailing slashes. Backslashes are only converted on
+// Windows.
+func normalizeStorePath(path string) string {
+	return strings.Trim(ToSlash(path), "/")
+}
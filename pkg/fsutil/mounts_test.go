@@ -0,0 +1,69 @@
+package fsutil
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMount(t *testing.T) {
+	t.Parallel()
+
+	mounts := []string{"team", "team/infra", "team-secret", "personal/"}
+
+	for _, tc := range []struct {
+		path  string
+		mount string
+		sub   string
+		ok    bool
+	}{
+		{path: "team/foo", mount: "team", sub: "foo", ok: true},
+		{path: "team/infra/db/root", mount: "team/infra", sub: "db/root", ok: true},
+		{path: "team/infrastructure/db", mount: "team", sub: "infrastructure/db", ok: true},
+		{path: "team-secret/foo", mount: "team-secret", sub: "foo", ok: true},
+		{path: "personal/bank", mount: "personal/", sub: "bank", ok: true},
+		{path: "team", mount: "team", sub: "", ok: true},
+		{path: "team/", mount: "team", sub: "", ok: true},
+		{path: "teams/foo", ok: false},
+		{path: "foo", ok: false},
+	} {
+		mount, sub, ok := SplitMount(mounts, tc.path)
+		assert.Equal(t, tc.ok, ok, tc.path)
+		assert.Equal(t, tc.mount, mount, tc.path)
+		assert.Equal(t, tc.sub, sub, tc.path)
+	}
+
+	// the root store matches everything not matched by another mount
+	mount, sub, ok := SplitMount(append([]string{""}, mounts...), "foo/bar")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "", mount)
+	assert.Equal(t, "foo/bar", sub)
+
+	mount, sub, ok = SplitMount(append(mounts, ""), "team/infra/db")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "team/infra", mount)
+	assert.Equal(t, "db", sub)
+
+	// backslashes are only separators on Windows
+	mount, sub, ok = SplitMount(mounts, `team/foo\bar`)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "team", mount)
+
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, "foo/bar", sub)
+
+		mount, sub, ok = SplitMount(mounts, `team\infra\db`)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "team/infra", mount)
+		assert.Equal(t, "db", sub)
+	} else {
+		assert.Equal(t, `foo\bar`, sub)
+
+		_, _, ok = SplitMount(mounts, `team\infra\db`)
+		assert.Equal(t, false, ok)
+	}
+
+	_, _, ok = SplitMount(nil, "foo")
+	assert.Equal(t, false, ok)
+}
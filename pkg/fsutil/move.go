@@ -0,0 +1,198 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// MoveFile renames src to dst. If both are on different filesystems the
+// content is copied to dst (preserving its mode), synced to disk and src is
+// removed afterwards. src is left untouched if the copy fails.
+func MoveFile(src, dst string) error {
+	return moveFile(src, dst, os.Rename, os.Remove)
+}
+
+// MoveFileSecure is like MoveFile but shreds src after copying it to another
+// filesystem instead of just removing it.
+func MoveFileSecure(src, dst string, rounds int) error {
+	return moveFile(src, dst, os.Rename, func(path string) error {
+		return Shred(path, rounds)
+	})
+}
+
+// ErrUnexpectedSymlink is returned by RenameNoFollow if the destination or
+// its parent directory is a symlink.
+var ErrUnexpectedSymlink = fmt.Errorf("unexpected symlink")
+
+// RenameNoFollow is like os.Rename but refuses to rename src if dst or the
+// directory containing dst is a symlink, which might have been planted to
+// redirect the rename somewhere else. Note that the check and the rename are
+// not atomic, so this only narrows the window for such an attack. Since
+// stores are often symlinked themselves, this is not used by
+// WriteFileAtomic.
+func RenameNoFollow(src, dst string) error {
+	for _, p := range []string{dst, filepath.Dir(dst)} {
+		if IsSymlink(p) {
+			return fmt.Errorf("can not rename %q to %q: %q is a symlink: %w", src, dst, p, ErrUnexpectedSymlink)
+		}
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("failed to rename %q to %q: %w", src, dst, err)
+	}
+
+	return nil
+}
+
+func moveFile(src, dst string, rename func(string, string) error, remove func(string) error) error {
+	src, dst = fixLongPath(src), fixLongPath(dst)
+
+	// renaming a hard link onto another link of the same file succeeds
+	// without removing src
+	same, err := SameFile(src, dst)
+	if err != nil {
+		return err
+	}
+
+	if same {
+		return fmt.Errorf("can not move %q to %q: %w", src, dst, ErrSameFile)
+	}
+
+	err = rename(src, dst)
+	if err == nil {
+		return SyncDir(filepath.Dir(dst))
+	}
+
+	if !errors.Is(err, errCrossDevice) {
+		return fmt.Errorf("failed to rename %q to %q: %w", src, dst, err)
+	}
+
+	debug.Log("can not rename %s to %s, copying instead: %s", src, dst, err)
+
+	if err := copyAtomic(src, dst); err != nil {
+		return err
+	}
+
+	if err := remove(src); err != nil {
+		return fmt.Errorf("failed to remove %q after copying: %w", src, err)
+	}
+
+	return nil
+}
+
+// copyAtomic copies the regular file src to a temporary file next to dst and
+// renames it over dst afterwards, so an existing dst is either replaced
+// completely or left as is.
+func copyAtomic(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", src, err)
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %q: %w", src, err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %q", src)
+	}
+
+	if err := copyReplace(in, dst, fi.Mode().Perm(), nil); err != nil {
+		return err
+	}
+
+	return SyncDir(filepath.Dir(dst))
+}
+
+// MoveDirSecure moves the directory src to dst by copying it with CopyDir,
+// comparing the copy with the source like TreeEqual, but including .git and
+// ignored files, and finally shredding src with ShredDir. dst must not exist
+// or be empty. If the copy or the verification fails src is left untouched
+// and everything copied to dst is removed again.
+func MoveDirSecure(src, dst string, rounds int) error {
+	existed := Exists(dst)
+	if existed {
+		entries, err := os.ReadDir(dst)
+		if err != nil {
+			return fmt.Errorf("failed to check destination %q: %w", dst, err)
+		}
+
+		// checked here already, so the cleanup never removes anything
+		// that was not copied by us
+		if len(entries) > 0 {
+			return fmt.Errorf("destination %q is not empty", dst)
+		}
+	}
+
+	if err := copyVerified(src, dst); err != nil {
+		if cerr := cleanupDir(dst, existed); cerr != nil {
+			debug.Log("failed to clean up %s: %s", dst, cerr)
+		}
+
+		return err
+	}
+
+	if err := ShredDir(src, rounds); err != nil {
+		return fmt.Errorf("copied %q to %q but failed to shred the source: %w", src, dst, err)
+	}
+
+	return nil
+}
+
+// copyVerified copies src to dst and checks that both trees are equal.
+func copyVerified(src, dst string) error {
+	if err := CopyDir(src, dst); err != nil {
+		return err
+	}
+
+	return verifyCopy(src, dst)
+}
+
+// verifyCopy checks that dst is an exact copy of src.
+func verifyCopy(src, dst string) error {
+	// everything in src is shredded afterwards, including .git and ignored
+	// files
+	eq, diffs, err := treeEqual(src, dst, WalkOpts{Ignorer: &Ignorer{}})
+	if err != nil {
+		return fmt.Errorf("failed to verify copy of %q: %w", src, err)
+	}
+
+	if !eq {
+		return fmt.Errorf("copy of %q does not match the source: %s", src, strings.Join(diffs, ", "))
+	}
+
+	return nil
+}
+
+// cleanupDir removes dir, or only its content if keep is set.
+func cleanupDir(dir string, keep bool) error {
+	if !keep {
+		return os.RemoveAll(dir) //nolint:wrapcheck
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read dir %q: %w", dir, err)
+	}
+
+	var result error
+
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !solaris && !aix && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!solaris,!aix,!windows
+
+package fsutil
+
+import "errors"
+
+// errCrossDevice is never returned by os.Rename on this platform, so
+// failed renames are not retried by copying.
+var errCrossDevice = errors.New("cross-device link")
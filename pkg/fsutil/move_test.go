@@ -0,0 +1,256 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveFile(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	dst := filepath.Join(tempdir, "dst")
+
+	require.NoError(t, os.WriteFile(src, []byte("foobar"), 0o600))
+	require.NoError(t, MoveFile(src, dst))
+	assert.Equal(t, false, IsFile(src))
+
+	buf, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", string(buf))
+
+	assert.Error(t, MoveFile(src, dst))
+}
+
+func TestMoveFileCrossDevice(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	xdev := func(src, dst string) error {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: errCrossDevice}
+	}
+
+	var removed []string
+
+	remove := func(path string) error {
+		removed = append(removed, path)
+
+		return os.Remove(path)
+	}
+
+	src := filepath.Join(tempdir, "src")
+	dst := filepath.Join(tempdir, "dst")
+
+	require.NoError(t, os.WriteFile(src, []byte("foobar"), 0o640))
+	require.NoError(t, os.WriteFile(dst, []byte("old"), 0o600))
+	require.NoError(t, moveFile(src, dst, xdev, remove))
+	assert.Equal(t, []string{src}, removed)
+	assert.Equal(t, false, IsFile(src))
+
+	buf, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", string(buf))
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(dst)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o640), fi.Mode().Perm())
+	}
+
+	// the source is kept if the copy fails
+	removed = nil
+	require.NoError(t, os.WriteFile(src, []byte("foobar"), 0o600))
+	assert.Error(t, moveFile(src, filepath.Join(tempdir, "non-existing", "dst"), xdev, remove))
+	assert.Empty(t, removed)
+	assert.Equal(t, true, IsFile(src))
+
+	// other errors are not retried
+	assert.Error(t, moveFile(src, dst, func(string, string) error {
+		return fmt.Errorf("some error")
+	}, remove))
+	assert.Empty(t, removed)
+
+	// the secure variant shreds the source
+	assert.NoError(t, moveFile(src, dst, xdev, func(path string) error {
+		return Shred(path, 1)
+	}))
+	assert.Equal(t, false, IsFile(src))
+
+	entries, err := os.ReadDir(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temp files left behind")
+}
+
+func TestMoveFileSecure(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	dst := filepath.Join(tempdir, "dst")
+
+	require.NoError(t, os.WriteFile(src, []byte("foobar"), 0o600))
+	require.NoError(t, MoveFileSecure(src, dst, 1))
+	assert.Equal(t, false, IsFile(src))
+	assert.Equal(t, true, IsFile(dst))
+}
+
+func TestMoveDirSecure(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	dst := filepath.Join(tempdir, "dst")
+
+	files := map[string]string{
+		".gpg-id":         "0xDEADBEEF",
+		".git/HEAD":       "ref: refs/heads/master",
+		"foo.gpg":         "foo",
+		"sub/bar.gpg":     "bar",
+		"sub/dir/baz.gpg": "baz",
+	}
+	for fn, content := range files {
+		fp := filepath.Join(src, filepath.FromSlash(fn))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0o700))
+		require.NoError(t, os.WriteFile(fp, []byte(content), 0o600))
+	}
+
+	// a second link to the same inode lets us look at the shredded content
+	// after the source has been removed
+	peek := filepath.Join(tempdir, "peek")
+	require.NoError(t, os.Link(filepath.Join(src, "sub", "bar.gpg"), peek))
+
+	require.NoError(t, MoveDirSecure(src, dst, 2))
+	assert.Equal(t, false, Exists(src))
+
+	for fn, content := range files {
+		buf, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(fn)))
+		require.NoError(t, err, fn)
+		assert.Equal(t, content, string(buf))
+	}
+
+	buf, err := os.ReadFile(peek)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, len("bar")), buf)
+
+	// a failed copy leaves the source alone and cleans up
+	require.NoError(t, os.Rename(dst, src))
+	assert.Error(t, MoveDirSecure(src, filepath.Join(tempdir, "missing", "dst"), 1))
+	assert.Equal(t, true, IsFile(filepath.Join(src, "sub", "dir", "baz.gpg")))
+	assert.Equal(t, false, Exists(filepath.Join(tempdir, "missing")))
+
+	// existing content is never overwritten or removed
+	require.NoError(t, os.MkdirAll(dst, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dst, "keep"), []byte("keep"), 0o600))
+	assert.Error(t, MoveDirSecure(src, dst, 1))
+	assert.Equal(t, true, IsFile(filepath.Join(dst, "keep")))
+	assert.Equal(t, true, IsFile(filepath.Join(src, "foo.gpg")))
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		return
+	}
+
+	// an unreadable file fails the copy, everything copied so far is
+	// removed from the existing but empty destination
+	require.NoError(t, os.Remove(filepath.Join(dst, "keep")))
+	require.NoError(t, os.Chmod(filepath.Join(src, "sub", "dir", "baz.gpg"), 0o000))
+	assert.Error(t, MoveDirSecure(src, dst, 1))
+	assert.Equal(t, true, IsDir(dst))
+
+	empty, err := IsEmptyDir(dst)
+	require.NoError(t, err)
+	assert.Equal(t, true, empty)
+
+	buf, err = os.ReadFile(filepath.Join(src, "foo.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(buf))
+}
+
+func TestVerifyCopy(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	dst := filepath.Join(tempdir, "dst")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(src, ".git", "objects"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, ".git", "objects", "pack"), []byte("history"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "foo.gpg"), []byte("foo"), 0o600))
+
+	require.NoError(t, CopyDir(src, dst))
+	require.NoError(t, verifyCopy(src, dst))
+
+	// .git is shredded as well, so it must be verified
+	require.NoError(t, os.WriteFile(filepath.Join(dst, ".git", "objects", "pack"), []byte("broken!"), 0o600))
+	err = verifyCopy(src, dst)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ".git/objects/pack")
+}
+
+func TestRenameNoFollow(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	require.NoError(t, os.WriteFile(src, []byte("foo"), 0o600))
+
+	target := filepath.Join(tempdir, "target")
+	require.NoError(t, os.Mkdir(target, 0o700))
+
+	// the parent is a symlink
+	link := filepath.Join(tempdir, "link")
+	require.NoError(t, os.Symlink(target, link))
+	assert.ErrorIs(t, RenameNoFollow(src, filepath.Join(link, "dst")), ErrUnexpectedSymlink)
+	assert.Equal(t, false, Exists(filepath.Join(target, "dst")))
+
+	// the destination is a symlink
+	require.NoError(t, os.Symlink(filepath.Join(target, "file"), filepath.Join(tempdir, "dst")))
+	assert.ErrorIs(t, RenameNoFollow(src, filepath.Join(tempdir, "dst")), ErrUnexpectedSymlink)
+	assert.Equal(t, true, IsFile(src))
+
+	require.NoError(t, RenameNoFollow(src, filepath.Join(target, "dst")))
+	assert.Equal(t, false, Exists(src))
+	assert.Equal(t, true, IsFile(filepath.Join(target, "dst")))
+}
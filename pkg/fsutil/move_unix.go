@@ -0,0 +1,10 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || aix
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris aix
+
+package fsutil
+
+import "syscall"
+
+// errCrossDevice is returned by os.Rename if source and destination are on
+// different filesystems.
+var errCrossDevice error = syscall.EXDEV
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import "golang.org/x/sys/windows"
+
+// errCrossDevice is returned by os.Rename if source and destination are on
+// different volumes.
+var errCrossDevice error = windows.ERROR_NOT_SAME_DEVICE
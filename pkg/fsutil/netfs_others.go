@@ -0,0 +1,40 @@
+//go:build !windows
+// +build !windows
+
+package fsutil
+
+import "errors"
+
+// networkFS contains the names of network filesystems as reported by
+// FilesystemType on the different platforms.
+var networkFS = map[string]bool{
+	"9p":           true,
+	"afpfs":        true,
+	"afs":          true,
+	"ceph":         true,
+	"cifs":         true,
+	"fuse.sshfs":   true,
+	"fusefs.sshfs": true,
+	"nfs":          true,
+	"nfs4":         true,
+	"smb":          true,
+	"smb2":         true,
+	"smbfs":        true,
+	"webdav":       true,
+}
+
+// IsNetworkFS returns true if path lives on a network filesystem like NFS,
+// SMB or SSHFS. Locking is often unreliable on those. If the filesystem type
+// can not be determined on this platform it returns false.
+func IsNetworkFS(path string) (bool, error) {
+	name, err := FilesystemType(path)
+	if errors.Is(err, ErrNotSupported) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return networkFS[name], nil
+}
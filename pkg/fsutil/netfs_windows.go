@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import "golang.org/x/sys/windows"
+
+// IsNetworkFS returns true if path lives on a network drive or share.
+// Locking is often unreliable on those.
+func IsNetworkFS(path string) (bool, error) {
+	root, err := volumeRoot(path)
+	if err != nil {
+		return false, err
+	}
+
+	return windows.GetDriveType(root) == windows.DRIVE_REMOTE, nil
+}
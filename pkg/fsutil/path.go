@@ -0,0 +1,351 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// CleanPath resolves common aliases in a path and cleans it as much as possible.
+// A leading ~ or ~user is replaced with the home directory of the current
+// or the given user. Unknown users are left as is.
+// Unlike the helpers accessing the filesystem, CleanPath does not add the
+// \\?\ prefix to long paths on Windows. Its result is shown to users, stored
+// in the config and compared with other paths, e.g. using filepath.Rel,
+// which only works if both sides have the same form.
+func CleanPath(path string) string {
+	path = expandHome(path)
+
+	if p, err := filepath.Abs(path); err == nil {
+		return p
+	}
+
+	return filepath.Clean(path)
+}
+
+// CleanPathKeepTrailing is like CleanPath but keeps a single trailing path
+// separator, e.g. to signal that the path refers to a directory.
+func CleanPathKeepTrailing(path string) string {
+	p := CleanPath(path)
+
+	if path == "" || !os.IsPathSeparator(path[len(path)-1]) {
+		return p
+	}
+
+	if os.IsPathSeparator(p[len(p)-1]) {
+		return p
+	}
+
+	return p + string(filepath.Separator)
+}
+
+// RealCasePath cleans the path and returns it with each component spelled
+// as it is stored on disk. This only makes a difference on case-insensitive
+// filesystems, e.g. on macOS or Windows. Symlinks are not resolved.
+// It fails if any component does not exist.
+func RealCasePath(path string) (string, error) {
+	p := CleanPath(path)
+	vol := filepath.VolumeName(p)
+	real := vol + string(filepath.Separator)
+
+	for _, name := range strings.Split(strings.Trim(p[len(vol):], string(filepath.Separator)), string(filepath.Separator)) {
+		if name == "" {
+			continue
+		}
+
+		if _, err := os.Lstat(filepath.Join(real, name)); err != nil {
+			return "", fmt.Errorf("failed to stat %q: %w", filepath.Join(real, name), err)
+		}
+
+		names, err := readDirNames(real)
+		if err != nil {
+			return "", err
+		}
+
+		real = filepath.Join(real, matchCase(name, names))
+	}
+
+	return real, nil
+}
+
+func readDirNames(dir string) ([]string, error) {
+	fh, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dir %q: %w", dir, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	names, err := fh.Readdirnames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dir %q: %w", dir, err)
+	}
+
+	return names, nil
+}
+
+// matchCase returns the entry of names that matches name exactly or,
+// failing that, case-insensitively. name is returned as is if nothing
+// matches.
+func matchCase(name string, names []string) string {
+	fold := ""
+
+	for _, n := range names {
+		if n == name {
+			return n
+		}
+
+		if fold == "" && strings.EqualFold(n, name) {
+			fold = n
+		}
+	}
+
+	if fold != "" {
+		return fold
+	}
+
+	return name
+}
+
+// CleanPathResolve is like CleanPath but also resolves all symlinks. This
+// requires the path to exist. Use this instead of CleanPath when the real
+// location of a path matters, e.g. for security checks.
+func CleanPathResolve(path string) (string, error) {
+	p, err := filepath.EvalSymlinks(CleanPath(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %q: %w", path, err)
+	}
+
+	return p, nil
+}
+
+// IsSubPath returns true if candidate is root or lives below root after
+// resolving all symlinks. candidate does not need to exist, in that case the
+// longest existing prefix is resolved.
+func IsSubPath(root, candidate string) (bool, error) {
+	r, err := resolveExisting(root)
+	if err != nil {
+		return false, err
+	}
+
+	c, err := resolveExisting(candidate)
+	if err != nil {
+		return false, err
+	}
+
+	return isLexicalSubPath(r, c), nil
+}
+
+// ConfinePath joins rel to root and cleans the result, resolving any ".."
+// elements lexically. It returns ErrOutsideRoot if the result would not be
+// root or below root, or if rel is absolute. It never accesses the
+// filesystem, so unlike IsSubPath symlinks are not taken into account.
+func ConfinePath(root, rel string) (string, error) {
+	rel = filepath.FromSlash(rel)
+	if filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" || strings.HasPrefix(rel, string(filepath.Separator)) {
+		return "", fmt.Errorf("can not confine absolute path %q: %w", rel, ErrOutsideRoot)
+	}
+
+	root = filepath.Clean(root)
+	p := filepath.Join(root, rel)
+
+	if !isLexicalSubPath(root, p) {
+		return "", fmt.Errorf("%q escapes %q: %w", rel, root, ErrOutsideRoot)
+	}
+
+	return p, nil
+}
+
+// Ancestors returns the directories between root and path, starting with
+// the first directory below root and ending with the parent of path. Both
+// paths are cleaned with CleanPath but not resolved, so they don't need to
+// exist. It returns ErrOutsideRoot if path is not below root.
+func Ancestors(root, path string) ([]string, error) {
+	r := CleanPath(root)
+	p := CleanPath(path)
+
+	if p == r || !isLexicalSubPath(r, p) {
+		return nil, fmt.Errorf("%q is not below %q: %w", path, root, ErrOutsideRoot)
+	}
+
+	var out []string
+	for dir := filepath.Dir(p); dir != r; dir = filepath.Dir(dir) {
+		out = append([]string{dir}, out...)
+	}
+
+	return out, nil
+}
+
+// isLexicalSubPath returns true if the clean, absolute path candidate is
+// root or below root.
+func isLexicalSubPath(root, candidate string) bool {
+	rel, err := filepath.Rel(root, candidate)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveExisting cleans the path and resolves the symlinks of its longest
+// existing prefix.
+func resolveExisting(path string) (string, error) {
+	p := CleanPath(path)
+
+	var rest []string
+
+	for {
+		r, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(append([]string{r}, rest...)...), nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to resolve %q: %w", path, err)
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", fmt.Errorf("failed to resolve %q: %w", path, err)
+		}
+
+		rest = append([]string{filepath.Base(p)}, rest...)
+		p = parent
+	}
+}
+
+var reWindowsEnv = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_]*)%`)
+
+// CleanPathExpand is like CleanPath but expands environment variables
+// ($VAR or ${VAR} and %VAR% on Windows) first. Undefined variables expand
+// to the empty string.
+func CleanPathExpand(path string) string {
+	path = os.ExpandEnv(path)
+	if runtime.GOOS == "windows" {
+		path = expandWindowsEnv(path)
+	}
+
+	return CleanPath(path)
+}
+
+// expandWindowsEnv replaces %VAR% with the value of the environment
+// variable VAR.
+func expandWindowsEnv(path string) string {
+	return reWindowsEnv.ReplaceAllStringFunc(path, func(m string) string {
+		return os.Getenv(m[1 : len(m)-1])
+	})
+}
+
+var reDriveLetter = regexp.MustCompile(`^/[A-Za-z]:`)
+
+// CleanPathURL converts a file:// URL (e.g. file:///home/user/store or
+// file:///C:/Users/user/store) to a native path and cleans it with
+// CleanPath. Percent-escapes are decoded. Other schemes and remote hosts
+// are rejected.
+func CleanPathURL(raw string) (string, error) {
+	p, err := fileURLPath(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return CleanPath(filepath.FromSlash(p)), nil
+}
+
+// fileURLPath returns the slash separated path of a file URL.
+func fileURLPath(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL %q: %w", raw, err)
+	}
+
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported URL scheme %q in %q", u.Scheme, raw)
+	}
+
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("unsupported remote host %q in %q", u.Host, raw)
+	}
+
+	p := u.Path
+	if p == "" {
+		// file:foo
+		p = u.Opaque
+	}
+
+	if p == "" {
+		return "", fmt.Errorf("no path in %q", raw)
+	}
+
+	// file:///C:/foo has the path /C:/foo
+	if reDriveLetter.MatchString(p) {
+		p = p[1:]
+	}
+
+	return p, nil
+}
+
+// DefaultStoreRoot returns the default location of a password store, like
+// pass would use it. GOPASS_HOMEDIR takes precedence over
+// PASSWORD_STORE_DIR, otherwise the store lives in ~/.password-store.
+// This does not check whether the store exists.
+func DefaultStoreRoot() string {
+	if hd := os.Getenv("GOPASS_HOMEDIR"); hd != "" {
+		return CleanPath(filepath.Join(hd, ".password-store"))
+	}
+
+	if d := os.Getenv("PASSWORD_STORE_DIR"); d != "" {
+		return CleanPath(d)
+	}
+
+	return CleanPath("~/.password-store")
+}
+
+// expandHome replaces a leading ~ or ~user with the corresponding home
+// directory. GOPASS_HOMEDIR overrides the home of the current user.
+func expandHome(path string) string {
+	// http://stackoverflow.com/questions/17609732/expand-tilde-to-home-directory
+	// TODO(GH-2083): We should consider if we really want to rewrite ~
+	if !strings.HasPrefix(path, "~") {
+		return path
+	}
+
+	name, rest := path[1:], ""
+	if i := strings.IndexByte(name, '/'); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+
+	if name != "" {
+		usr, err := user.Lookup(name)
+		if err != nil {
+			debug.Log("failed to lookup user %q: %s", name, err)
+
+			return path
+		}
+
+		return usr.HomeDir + rest
+	}
+
+	if hd := os.Getenv("GOPASS_HOMEDIR"); hd != "" {
+		return hd + rest
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		debug.Log("failed to get current user: %s", err)
+
+		return path
+	}
+
+	return usr.HomeDir + rest
+}
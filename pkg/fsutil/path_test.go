@@ -0,0 +1,345 @@
+package fsutil
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanPath(t *testing.T) { //nolint:paralleltest
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	m := map[string]string{
+		".":                                 "",
+		"/home/user/../bob/.password-store": "/home/bob/.password-store",
+		"/home/user//.password-store":       "/home/user/.password-store",
+		tempdir + "/foo.gpg":                tempdir + "/foo.gpg",
+	}
+
+	usr, err := user.Current()
+	if err == nil {
+		hd := usr.HomeDir
+		if gph := os.Getenv("GOPASS_HOMEDIR"); gph != "" {
+			hd = gph
+		}
+
+		m["~/.password-store"] = hd + "/.password-store"
+		m["~"] = hd
+		m["~/"] = hd
+		m["~"+usr.Username+"/.password-store"] = usr.HomeDir + "/.password-store"
+	}
+
+	m["~gopass-nonexisting-user/path"] = "~gopass-nonexisting-user/path"
+
+	for in, out := range m {
+		got := CleanPath(in)
+
+		// filepath.Abs turns /home/bob into C:\home\bob on Windows
+		absOut, err := filepath.Abs(out)
+		assert.NoError(t, err)
+		assert.Equal(t, absOut, got)
+	}
+}
+
+func TestCleanPathHomedir(t *testing.T) { //nolint:paralleltest
+	td, err := filepath.Abs(filepath.Join("testdata", "home"))
+	require.NoError(t, err)
+
+	old, isSet := os.LookupEnv("GOPASS_HOMEDIR")
+	require.NoError(t, os.Setenv("GOPASS_HOMEDIR", td))
+
+	defer func() {
+		if isSet {
+			_ = os.Setenv("GOPASS_HOMEDIR", old)
+
+			return
+		}
+
+		_ = os.Unsetenv("GOPASS_HOMEDIR")
+	}()
+
+	assert.Equal(t, td, CleanPath("~"))
+	assert.Equal(t, filepath.Join(td, ".password-store"), CleanPath("~/.password-store"))
+}
+
+func TestDefaultStoreRoot(t *testing.T) { //nolint:paralleltest
+	td, err := filepath.Abs(filepath.Join("testdata", "home"))
+	require.NoError(t, err)
+
+	sd, err := filepath.Abs(filepath.Join("testdata", "store"))
+	require.NoError(t, err)
+
+	t.Setenv("GOPASS_HOMEDIR", td)
+	t.Setenv("PASSWORD_STORE_DIR", sd)
+	assert.Equal(t, filepath.Join(td, ".password-store"), DefaultStoreRoot())
+
+	t.Setenv("GOPASS_HOMEDIR", "")
+	assert.Equal(t, sd, DefaultStoreRoot())
+
+	t.Setenv("PASSWORD_STORE_DIR", "")
+	assert.Equal(t, CleanPath("~/.password-store"), DefaultStoreRoot())
+	assert.Equal(t, ".password-store", filepath.Base(DefaultStoreRoot()))
+}
+
+func TestCleanPathExpand(t *testing.T) { //nolint:paralleltest
+	td, err := filepath.Abs(filepath.Join("testdata", "data"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.Setenv("GOPASS_TEST_DATA_HOME", td))
+
+	defer func() {
+		_ = os.Unsetenv("GOPASS_TEST_DATA_HOME")
+	}()
+
+	want := filepath.Join(td, "gopass", "stores")
+	assert.Equal(t, want, CleanPathExpand("$GOPASS_TEST_DATA_HOME/gopass/stores"))
+	assert.Equal(t, want, CleanPathExpand("${GOPASS_TEST_DATA_HOME}/gopass/stores"))
+	assert.Equal(t, want, CleanPathExpand("${GOPASS_TEST_DATA_HOME}/gopass/$GOPASS_TEST_UNDEFINED/stores"))
+
+	// idempotent
+	assert.Equal(t, want, CleanPathExpand(CleanPathExpand("$GOPASS_TEST_DATA_HOME/gopass/stores")))
+
+	assert.Equal(t, td+`\foo`, expandWindowsEnv(`%GOPASS_TEST_DATA_HOME%\foo`))
+	assert.Equal(t, `\foo`, expandWindowsEnv(`%GOPASS_TEST_UNDEFINED%\foo`))
+	assert.Equal(t, `50% off`, expandWindowsEnv(`50% off`))
+}
+
+func TestCleanPathResolve(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	// the tempdir itself might be behind a symlink, e.g. on macOS
+	tempdir, err = filepath.EvalSymlinks(tempdir)
+	require.NoError(t, err)
+
+	secrets := filepath.Join(tempdir, "secrets")
+	require.NoError(t, os.Mkdir(secrets, 0o700))
+
+	store := filepath.Join(tempdir, "store")
+	require.NoError(t, os.Symlink(secrets, store))
+
+	got, err := CleanPathResolve(store)
+	require.NoError(t, err)
+	assert.Equal(t, secrets, got)
+
+	got, err = CleanPathResolve(filepath.Join(store, "..", "store"))
+	require.NoError(t, err)
+	assert.Equal(t, secrets, got)
+
+	assert.Equal(t, store, CleanPath(store))
+
+	_, err = CleanPathResolve(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
+
+func TestIsSubPath(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	root := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "foo"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "store-evil"), 0o700))
+	require.NoError(t, os.Symlink(tempdir, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "foo"), filepath.Join(root, "internal")))
+
+	for candidate, want := range map[string]bool{
+		root:                                  true,
+		filepath.Join(root, "foo"):            true,
+		filepath.Join(root, "foo", "bar.gpg"): true,
+		filepath.Join(root, "new", "deeply", "nested.gpg"):   true,
+		filepath.Join(root, "internal", "bar.gpg"):           true,
+		filepath.Join(root, "..", "..", "etc", "passwd"):     false,
+		filepath.Join(root, "foo", "..", "..", "store-evil"): false,
+		filepath.Join(tempdir, "store-evil"):                 false,
+		filepath.Join(tempdir, "store-evil", "foo.gpg"):      false,
+		filepath.Join(root, "escape", "store-evil"):          false,
+		filepath.Join(root, "escape"):                        false,
+		tempdir:                                              false,
+	} {
+		got, err := IsSubPath(root, candidate)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, candidate)
+	}
+}
+
+func TestCleanPathURL(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{in: "file:///home/user/store", want: "/home/user/store"},
+		{in: "file://localhost/home/user/store", want: "/home/user/store"},
+		{in: "file:///home/user/my%20store/", want: "/home/user/my store/"},
+		{in: "file:///C:/Users/user/store", want: "C:/Users/user/store"},
+		{in: "FILE:///c:/store", want: "c:/store"},
+	} {
+		p, err := fileURLPath(tc.in)
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, p, tc.in)
+	}
+
+	if runtime.GOOS == "windows" {
+		p, err := CleanPathURL("file:///C:/Users/user/My%20Store")
+		require.NoError(t, err)
+		assert.Equal(t, `C:\Users\user\My Store`, p)
+	} else {
+		p, err := CleanPathURL("file:///home/user/my%20store/../store/")
+		require.NoError(t, err)
+		assert.Equal(t, "/home/user/store", p)
+	}
+
+	for _, in := range []string{
+		"https://example.com/store",
+		"/home/user/store",
+		"file://example.com/store",
+		"file://",
+		"%zz",
+	} {
+		_, err := CleanPathURL(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestCleanPathKeepTrailing(t *testing.T) {
+	t.Parallel()
+
+	sep := string(filepath.Separator)
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	assert.Equal(t, filepath.Join(tempdir, "foo")+sep, CleanPathKeepTrailing(tempdir+sep+"foo"+sep))
+	assert.Equal(t, filepath.Join(tempdir, "foo")+sep, CleanPathKeepTrailing(tempdir+sep+"foo"+sep+sep))
+	assert.Equal(t, filepath.Join(tempdir, "foo")+sep, CleanPathKeepTrailing(tempdir+sep+"bar"+sep+".."+sep+"foo"+sep))
+	assert.Equal(t, filepath.Join(tempdir, "foo"), CleanPathKeepTrailing(tempdir+sep+"foo"))
+	assert.Equal(t, CleanPath(sep), CleanPathKeepTrailing(sep))
+}
+
+func TestRealCasePath(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	// the tempdir itself might be a symlink or use a different case on disk
+	tempdir, err = RealCasePath(tempdir)
+	require.NoError(t, err)
+
+	dir := filepath.Join(tempdir, "Store", "SubDir")
+	require.NoError(t, os.MkdirAll(dir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Secret.gpg"), []byte("foo"), 0o600))
+
+	p, err := RealCasePath(filepath.Join(dir, "Secret.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "Secret.gpg"), p)
+
+	_, err = RealCasePath(filepath.Join(dir, "non-existing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	lower := filepath.Join(tempdir, "store", "subdir", "secret.gpg")
+	if !IsFile(lower) {
+		// case-sensitive filesystem
+		_, err = RealCasePath(lower)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		return
+	}
+
+	p, err = RealCasePath(lower)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "Secret.gpg"), p)
+}
+
+func TestConfinePath(t *testing.T) {
+	t.Parallel()
+
+	root := filepath.Join("store", "root")
+
+	for _, tc := range []struct {
+		rel  string
+		want string
+	}{
+		{rel: "foo", want: filepath.Join(root, "foo")},
+		{rel: "foo/bar/baz.gpg", want: filepath.Join(root, "foo", "bar", "baz.gpg")},
+		{rel: "foo/../bar", want: filepath.Join(root, "bar")},
+		{rel: "a/b/c/../../../d", want: filepath.Join(root, "d")},
+		{rel: "foo/..", want: root},
+		{rel: "", want: root},
+		{rel: "./foo//bar/", want: filepath.Join(root, "foo", "bar")},
+		{rel: "..foo", want: filepath.Join(root, "..foo")},
+	} {
+		p, err := ConfinePath(root, tc.rel)
+		require.NoError(t, err, tc.rel)
+		assert.Equal(t, tc.want, p, tc.rel)
+	}
+
+	for _, rel := range []string{
+		"..",
+		"../root2",
+		"a/../../b",
+		"a/b/../../../../../../etc/passwd",
+		"/etc/passwd",
+	} {
+		_, err := ConfinePath(root, rel)
+		assert.ErrorIs(t, err, ErrOutsideRoot, rel)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	t.Parallel()
+
+	root := CleanPath(filepath.Join("store", "root"))
+
+	dirs, err := Ancestors(root, filepath.Join(root, "a", "b", "c", "secret.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "a"),
+		filepath.Join(root, "a", "b"),
+		filepath.Join(root, "a", "b", "c"),
+	}, dirs)
+
+	dirs, err = Ancestors(root, filepath.Join(root, "secret.gpg"))
+	require.NoError(t, err)
+	assert.Empty(t, dirs)
+
+	for _, p := range []string{
+		root,
+		filepath.Join(root, "..", "other", "secret.gpg"),
+		filepath.Join(root+"2", "secret.gpg"),
+	} {
+		_, err = Ancestors(root, p)
+		assert.ErrorIs(t, err, ErrOutsideRoot, p)
+	}
+}
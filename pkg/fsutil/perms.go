@@ -0,0 +1,117 @@
+package fsutil
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// HasInsecurePerms returns true if the regular file at path can be read or
+// written by its group or by others. This is always false on Windows, where
+// the mode bits do not reflect the actual ACLs.
+func HasInsecurePerms(path string) (bool, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		return false, fmt.Errorf("not a regular file: %q", path)
+	}
+
+	if runtime.GOOS == "windows" {
+		return false, nil
+	}
+
+	return fi.Mode().Perm()&0o066 != 0, nil
+}
+
+// FixPerms changes the mode of path to mode.
+func FixPerms(path string, mode os.FileMode) error {
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to chmod %q to %s: %w", path, mode, err)
+	}
+
+	return nil
+}
+
+// FixPermsTree changes the mode of every regular file below root that grants
+// more than fileMode to fileMode and of every directory (including root)
+// that grants more than dirMode to dirMode. Symlinks and .git are skipped.
+// It returns the list of changed paths. Errors for individual entries do not
+// abort the sweep.
+func FixPermsTree(root string, fileMode, dirMode os.FileMode) ([]string, error) {
+	return chmodTree(root, fileMode, dirMode, true)
+}
+
+// ChmodTree sets the mode of every regular file below root to fileMode and
+// of every directory (including root) to dirMode. Symlinks are skipped, so
+// their targets are never changed. Errors for individual entries do not abort
+// the operation, they are collected and returned at the end.
+func ChmodTree(root string, fileMode, dirMode os.FileMode) error {
+	_, err := chmodTree(root, fileMode, dirMode, false)
+
+	return err
+}
+
+// chmodTree implements ChmodTree and, if fixOnly is set, FixPermsTree.
+func chmodTree(root string, fileMode, dirMode os.FileMode, fixOnly bool) ([]string, error) {
+	var fixed []string
+
+	var result error
+
+	opts := WalkOpts{
+		SkipGit: fixOnly,
+		// ignored files might still grant too much access, so visit everything
+		Ignorer: &Ignorer{},
+		OnReadDirError: func(path string, err error) error {
+			result = multierror.Append(result, err)
+
+			return nil
+		},
+	}
+
+	err := Walk(root, opts, func(path string, d fs.DirEntry) error {
+		var want os.FileMode
+
+		switch {
+		case d.IsDir():
+			want = dirMode
+		case d.Type().IsRegular():
+			want = fileMode
+		default:
+			return nil
+		}
+
+		if fixOnly {
+			fi, err := d.Info()
+			if err != nil {
+				result = multierror.Append(result, fmt.Errorf("failed to stat %q: %w", path, err))
+
+				return nil
+			}
+
+			if fi.Mode().Perm()&^want == 0 {
+				return nil
+			}
+		}
+
+		if err := FixPerms(path, want); err != nil {
+			result = multierror.Append(result, err)
+
+			return nil
+		}
+
+		fixed = append(fixed, path)
+
+		return nil
+	})
+	if err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	return fixed, result
+}
@@ -0,0 +1,196 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasInsecurePerms(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("mode bits are not meaningful on windows")
+	}
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret.gpg")
+	require.NoError(t, os.WriteFile(fn, []byte("foo"), 0o600))
+	require.NoError(t, os.Chmod(fn, 0o644))
+
+	insecure, err := HasInsecurePerms(fn)
+	require.NoError(t, err)
+	assert.Equal(t, true, insecure)
+
+	require.NoError(t, FixPerms(fn, 0o600))
+
+	insecure, err = HasInsecurePerms(fn)
+	require.NoError(t, err)
+	assert.Equal(t, false, insecure)
+
+	_, err = HasInsecurePerms(tempdir)
+	assert.Error(t, err)
+
+	_, err = HasInsecurePerms(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
+
+func TestFixPermsTree(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("mode bits are not meaningful on windows")
+	}
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	root := filepath.Join(tempdir, "store")
+	sub := filepath.Join(root, "sub")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0o700))
+	require.NoError(t, os.MkdirAll(sub, 0o700))
+
+	for path, mode := range map[string]os.FileMode{
+		root:                              0o700,
+		sub:                               0o755,
+		filepath.Join(root, "ok.gpg"):     0o600,
+		filepath.Join(root, "ro.gpg"):     0o400,
+		filepath.Join(sub, "leak.gpg"):    0o644,
+		filepath.Join(root, ".git", "cf"): 0o644,
+	} {
+		if !IsDir(path) {
+			require.NoError(t, os.WriteFile(path, []byte("foo"), 0o600))
+		}
+
+		require.NoError(t, os.Chmod(path, mode))
+	}
+
+	require.NoError(t, os.Symlink(filepath.Join(sub, "leak.gpg"), filepath.Join(root, "link.gpg")))
+
+	fixed, err := FixPermsTree(root, 0o600, 0o700)
+	require.NoError(t, err)
+	assert.Equal(t, []string{sub, filepath.Join(sub, "leak.gpg")}, fixed)
+
+	for path, mode := range map[string]os.FileMode{
+		sub:                               0o700,
+		filepath.Join(root, "ro.gpg"):     0o400,
+		filepath.Join(sub, "leak.gpg"):    0o600,
+		filepath.Join(root, ".git", "cf"): 0o644,
+	} {
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, mode, fi.Mode().Perm(), path)
+	}
+
+	fixed, err = FixPermsTree(root, 0o600, 0o700)
+	require.NoError(t, err)
+	assert.Empty(t, fixed)
+}
+
+func TestFixPermsTreeUnreadable(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("mode bits are not meaningful on windows")
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any directory")
+	}
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	locked := filepath.Join(tempdir, "a")
+	leak := filepath.Join(tempdir, "b", "leak.gpg")
+
+	defer func() {
+		_ = os.Chmod(locked, 0o700)
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	require.NoError(t, os.MkdirAll(locked, 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Dir(leak), 0o700))
+	require.NoError(t, os.WriteFile(leak, []byte("foo"), 0o644))
+	require.NoError(t, os.Chmod(leak, 0o644))
+	// grants nothing, so it's not fixed and stays unreadable
+	require.NoError(t, os.Chmod(locked, 0o000))
+
+	fixed, err := FixPermsTree(tempdir, 0o600, 0o700)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), locked)
+	assert.Equal(t, []string{leak}, fixed)
+
+	fi, err := os.Stat(leak)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+}
+
+func TestChmodTree(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("mode bits are not meaningful on windows")
+	}
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	outside := filepath.Join(tempdir, "outside")
+	require.NoError(t, os.WriteFile(outside, []byte("foo"), 0o644))
+	require.NoError(t, os.Chmod(outside, 0o644))
+
+	root := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "a", "b"), 0o755))
+
+	files := []string{
+		filepath.Join(root, "top.gpg"),
+		filepath.Join(root, "a", "one.gpg"),
+		filepath.Join(root, "a", "b", "two.gpg"),
+	}
+	for _, fn := range files {
+		require.NoError(t, os.WriteFile(fn, []byte("foo"), 0o644))
+		require.NoError(t, os.Chmod(fn, 0o444))
+	}
+
+	require.NoError(t, os.Chmod(filepath.Join(root, "a", "b"), 0o555))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "a", "link")))
+
+	require.NoError(t, ChmodTree(root, 0o600, 0o700))
+
+	for _, dir := range []string{root, filepath.Join(root, "a"), filepath.Join(root, "a", "b")} {
+		fi, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o700), fi.Mode().Perm(), dir)
+	}
+
+	for _, fn := range files {
+		fi, err := os.Stat(fn)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm(), fn)
+	}
+
+	fi, err := os.Stat(outside)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o644), fi.Mode().Perm())
+
+	assert.Error(t, ChmodTree(filepath.Join(tempdir, "non-existing"), 0o600, 0o700))
+}
@@ -0,0 +1,197 @@
+package fsutil
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// ErrFileTooLarge is returned if a file exceeds the size limit passed to
+// ReadFileLimit.
+var ErrFileTooLarge = fmt.Errorf("file too large")
+
+// ReadFileLimit is like os.ReadFile but refuses to read files larger than
+// max bytes. The size is checked before reading and enforced again while
+// reading, in case the file grows in the meantime.
+func ReadFileLimit(path string, max int64) ([]byte, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	if fi.Size() > max {
+		return nil, fmt.Errorf("%q has %d bytes, limit is %d: %w", path, fi.Size(), max, ErrFileTooLarge)
+	}
+
+	return readLimit(fh, path, max)
+}
+
+// ReadAllSafe is like ReadFileLimit but also supports inputs that are not
+// regular files, e.g. named pipes or /dev/stdin. Their reported size is
+// meaningless, so the limit is only enforced while reading. Note that
+// opening a named pipe blocks until the other end is opened for writing.
+func ReadAllSafe(path string, max int64) ([]byte, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	if fi.Mode().IsRegular() && fi.Size() > max {
+		return nil, fmt.Errorf("%q has %d bytes, limit is %d: %w", path, fi.Size(), max, ErrFileTooLarge)
+	}
+
+	return readLimit(fh, path, max)
+}
+
+// readLimit reads r until EOF and fails with ErrFileTooLarge as soon as
+// more than max bytes have been read.
+func readLimit(r io.Reader, path string, max int64) ([]byte, error) {
+	// read one more byte than allowed to detect files that have grown
+	buf, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	if int64(len(buf)) > max {
+		return nil, fmt.Errorf("%q exceeds limit of %d bytes: %w", path, max, ErrFileTooLarge)
+	}
+
+	return buf, nil
+}
+
+// utf8BOM is the byte order mark some Windows tools put at the beginning
+// of UTF-8 encoded text files.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// NormalizeText removes a leading UTF-8 byte order mark and converts CRLF
+// line endings to LF. It must only be used on text, binary data would be
+// corrupted.
+func NormalizeText(buf []byte) []byte {
+	buf = bytes.TrimPrefix(buf, utf8BOM)
+
+	return bytes.ReplaceAll(buf, []byte("\r\n"), []byte("\n"))
+}
+
+// ReadNormalized reads the file at path and applies NormalizeText if the
+// content looks like text, i.e. it is valid UTF-8 without any NUL bytes.
+// Anything else, e.g. binary attachments, is returned as is.
+func ReadNormalized(path string) ([]byte, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	if !isText(buf) {
+		return buf, nil
+	}
+
+	return NormalizeText(buf), nil
+}
+
+func isText(buf []byte) bool {
+	return utf8.Valid(buf) && bytes.IndexByte(buf, 0) < 0
+}
+
+// CountLines returns the number of lines in the given file. The file is
+// read in chunks, so it's never loaded into memory as a whole. A last line
+// without a trailing newline is counted as well, an empty file has no lines.
+func CountLines(path string) (int, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	buf := make([]byte, 32*1024)
+	count := 0
+	last := byte('\n')
+
+	for {
+		n, err := fh.Read(buf)
+		if n > 0 {
+			count += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %q: %w", path, err)
+		}
+	}
+
+	if last != '\n' {
+		count++
+	}
+
+	return count, nil
+}
+
+// maxLineSize is the longest line EachLine accepts.
+const maxLineSize = 1024 * 1024
+
+// ErrStopIteration can be returned by the callback of EachLine to stop
+// early without an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// EachLine calls fn for every line in the given file, in order, without
+// loading the whole file into memory. Line endings (LF or CRLF) are
+// stripped and a last line without a trailing newline is passed as well.
+// Lines may be up to 1 MiB long. If fn returns ErrStopIteration EachLine
+// stops and returns nil, any other error from fn is returned as is.
+func EachLine(path string, fn func(line string) error) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	scanner := bufio.NewScanner(fh)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileLimit(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte("foobar"), 0o600))
+
+	buf, err := ReadFileLimit(fn, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", string(buf))
+
+	buf, err = ReadFileLimit(fn, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", string(buf))
+
+	_, err = ReadFileLimit(fn, 5)
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+
+	_, err = ReadFileLimit(filepath.Join(tempdir, "non-existing"), 1024)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCountLines(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{name: "empty", content: "", want: 0},
+		{name: "newline", content: "\n", want: 1},
+		{name: "single", content: "foo", want: 1},
+		{name: "trailing-newline", content: "foo\nbar\n", want: 2},
+		{name: "no-trailing-newline", content: "foo\nbar", want: 2},
+		{name: "crlf", content: "foo\r\nbar\r\nbaz", want: 3},
+		{name: "large", content: strings.Repeat("foobar\n", 100000), want: 100000},
+	} {
+		fn := filepath.Join(tempdir, tc.name)
+		require.NoError(t, os.WriteFile(fn, []byte(tc.content), 0o600))
+
+		n, err := CountLines(fn)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.want, n, tc.name)
+	}
+
+	_, err = CountLines(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
+
+func TestNormalizeText(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "secret\nuser: foo\n", want: "secret\nuser: foo\n"},
+		{in: "\xef\xbb\xbfsecret\n", want: "secret\n"},
+		{in: "secret\r\nuser: foo\r\n", want: "secret\nuser: foo\n"},
+		{in: "\xef\xbb\xbfsecret\r\nuser: foo", want: "secret\nuser: foo"},
+		// only a leading BOM is removed, lone CRs are kept
+		{in: "se\xef\xbb\xbfcret\rfoo", want: "se\xef\xbb\xbfcret\rfoo"},
+	} {
+		assert.Equal(t, tc.want, string(NormalizeText([]byte(tc.in))), tc.in)
+	}
+}
+
+func TestReadNormalized(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret.txt")
+	require.NoError(t, os.WriteFile(fn, []byte("\xef\xbb\xbfsecret\r\nuser: foo\r\n"), 0o600))
+
+	buf, err := ReadNormalized(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "secret\nuser: foo\n", string(buf))
+
+	// binary content is never touched
+	bin := []byte{0xef, 0xbb, 0xbf, 0x00, '\r', '\n', 0xff, 0xfe}
+	fn = filepath.Join(tempdir, "attachment.bin")
+	require.NoError(t, os.WriteFile(fn, bin, 0o600))
+
+	buf, err = ReadNormalized(fn)
+	require.NoError(t, err)
+	assert.Equal(t, bin, buf)
+
+	_, err = ReadNormalized(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
+
+func TestEachLine(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	collect := func(fn string) ([]string, error) {
+		lines := []string{}
+		err := EachLine(fn, func(line string) error {
+			lines = append(lines, line)
+
+			return nil
+		})
+
+		return lines, err
+	}
+
+	// no trailing newline, CRLF and empty lines
+	fn := filepath.Join(tempdir, "lines")
+	require.NoError(t, os.WriteFile(fn, []byte("foo\r\n\nbar\nbaz"), 0o600))
+
+	lines, err := collect(fn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "", "bar", "baz"}, lines)
+
+	// early stop
+	lines = []string{}
+	require.NoError(t, EachLine(fn, func(line string) error {
+		lines = append(lines, line)
+		if line == "" {
+			return ErrStopIteration
+		}
+
+		return nil
+	}))
+	assert.Equal(t, []string{"foo", ""}, lines)
+
+	// other errors are passed through
+	errFoo := errors.New("foo")
+	assert.ErrorIs(t, EachLine(fn, func(line string) error {
+		return errFoo
+	}), errFoo)
+
+	// long lines
+	long := strings.Repeat("a", 512*1024)
+	fn = filepath.Join(tempdir, "long")
+	require.NoError(t, os.WriteFile(fn, []byte(long+"\nfoo\n"), 0o600))
+
+	lines, err = collect(fn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{long, "foo"}, lines)
+
+	// but not too long
+	require.NoError(t, os.WriteFile(fn, []byte(strings.Repeat("a", 2*1024*1024)), 0o600))
+	_, err = collect(fn)
+	assert.Error(t, err)
+
+	// empty files have no lines
+	fn = filepath.Join(tempdir, "empty")
+	require.NoError(t, os.WriteFile(fn, nil, 0o600))
+
+	lines, err = collect(fn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, lines)
+
+	assert.Error(t, EachLine(filepath.Join(tempdir, "non-existing"), func(string) error { return nil }))
+}
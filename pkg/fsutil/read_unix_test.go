@@ -0,0 +1,63 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAllSafe(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte("foobar"), 0o600))
+
+	buf, err := ReadAllSafe(fn, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", string(buf))
+
+	_, err = ReadAllSafe(fn, 5)
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+
+	fifo := filepath.Join(tempdir, "fifo")
+	require.NoError(t, syscall.Mkfifo(fifo, 0o600))
+
+	write := func(content string) {
+		go func() {
+			fh, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+			if err != nil {
+				return
+			}
+
+			// the reader may stop early, so write errors are expected
+			_, _ = fh.WriteString(content)
+			_ = fh.Close()
+		}()
+	}
+
+	write("secret\n")
+
+	buf, err = ReadAllSafe(fifo, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "secret\n", string(buf))
+
+	write(strings.Repeat("x", 2048))
+
+	_, err = ReadAllSafe(fifo, 1024)
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+}
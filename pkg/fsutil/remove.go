@@ -0,0 +1,79 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrOutsideRoot is returned if a path does not live below the root it was
+// confined to.
+var ErrOutsideRoot = fmt.Errorf("path outside of root")
+
+// SafeRemove removes path, but only if it lives below root after resolving
+// all symlinks. Relative paths are interpreted relative to root. root itself
+// can not be removed this way.
+func SafeRemove(root, path string) error {
+	p, err := confined(root, path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil {
+		return fmt.Errorf("failed to remove %q: %w", p, err)
+	}
+
+	return nil
+}
+
+// SafeRemoveAll is like SafeRemove but removes path and all its children,
+// like os.RemoveAll. ErrDangerousPath is returned if path is a dangerous
+// path, see IsDangerousPath.
+func SafeRemoveAll(root, path string) error {
+	return SafeRemoveAllWithOpts(root, path, RemoveOpts{})
+}
+
+// SafeRemoveAllWithOpts is like SafeRemoveAll but behaves according to opts.
+func SafeRemoveAllWithOpts(root, path string, opts RemoveOpts) error {
+	p, err := confined(root, path)
+	if err != nil {
+		return err
+	}
+
+	if err := checkDangerous("remove", p, opts.AllowDangerousPath); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(p); err != nil {
+		return fmt.Errorf("failed to remove %q: %w", p, err)
+	}
+
+	return nil
+}
+
+// confined returns the absolute path of path if it is strictly below root.
+func confined(root, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(CleanPath(root), path)
+	}
+
+	r, err := resolveExisting(root)
+	if err != nil {
+		return "", err
+	}
+
+	// the link itself is removed, not its target. so only resolve the
+	// parent dir.
+	p, err := resolveExisting(filepath.Dir(CleanPath(path)))
+	if err != nil {
+		return "", err
+	}
+
+	p = filepath.Join(p, filepath.Base(path))
+
+	if p == r || !isLexicalSubPath(r, p) {
+		return "", fmt.Errorf("%q is not below %q: %w", path, root, ErrOutsideRoot)
+	}
+
+	return p, nil
+}
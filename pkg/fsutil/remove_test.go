@@ -0,0 +1,50 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeRemove(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	root := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub", "dir"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "file"), []byte("foo"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "dir", "file"), []byte("foo"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "foo"), []byte("foo"), 0o600))
+
+	// outside of the root
+	assert.ErrorIs(t, SafeRemove(root, "../foo"), ErrOutsideRoot)
+	assert.ErrorIs(t, SafeRemoveAll(root, "../foo"), ErrOutsideRoot)
+	assert.ErrorIs(t, SafeRemove(root, filepath.Join(tempdir, "foo")), ErrOutsideRoot)
+	assert.ErrorIs(t, SafeRemoveAll(root, "sub/../.."), ErrOutsideRoot)
+	assert.ErrorIs(t, SafeRemoveAll(root, "."), ErrOutsideRoot)
+	assert.Equal(t, true, IsFile(filepath.Join(tempdir, "foo")))
+	assert.Equal(t, true, IsDir(root))
+
+	// a symlink pointing outside is removed, not its target
+	require.NoError(t, os.Symlink(tempdir, filepath.Join(root, "link")))
+	assert.ErrorIs(t, SafeRemove(root, "link/foo"), ErrOutsideRoot)
+	assert.NoError(t, SafeRemove(root, "link"))
+	assert.Equal(t, true, IsFile(filepath.Join(tempdir, "foo")))
+
+	// inside of the root
+	assert.NoError(t, SafeRemove(root, "sub/file"))
+	assert.Equal(t, false, IsFile(filepath.Join(root, "sub", "file")))
+	assert.Error(t, SafeRemove(root, "sub"))
+	assert.NoError(t, SafeRemoveAll(root, filepath.Join(root, "sub")))
+	assert.Equal(t, false, IsDir(filepath.Join(root, "sub")))
+	assert.Equal(t, true, IsDir(root))
+}
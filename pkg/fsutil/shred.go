@@ -0,0 +1,677 @@
+package fsutil
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// shredCheckInterval is the number of bytes written between two checks for
+// context cancellation.
+const shredCheckInterval = 4 * 1024 * 1024
+
+// shredBlockSize is the default size of the blocks written by Shred.
+const shredBlockSize = 64 * 1024
+
+// shredEpoch is the time set by ShredOpts.ScrubTimestamps.
+var shredEpoch = time.Unix(0, 0)
+
+// shredScrubRenames is the number of times a file is renamed before it is
+// removed when ShredOpts.ScrubName is set.
+const shredScrubRenames = 3
+
+// ShredPass describes a single overwrite pass. A pass either writes
+// random data or repeats a fixed byte sequence over the whole file.
+// A pass without a Pattern is treated as random.
+type ShredPass struct {
+	Random  bool
+	Pattern []byte
+}
+
+// ShredPattern is the sequence of passes applied to a file before it
+// is removed.
+type ShredPattern []ShredPass
+
+var (
+	shredPassRandom = ShredPass{Random: true}
+	shredPassZero   = ShredPass{Pattern: []byte{0x00}}
+)
+
+// ShredRandom returns a pattern with the given number of passes. All but the
+// last pass write random data, the last one writes zeros.
+// This is the pattern used by Shred.
+func ShredRandom(rounds int) ShredPattern {
+	if rounds < 1 {
+		return ShredPattern{}
+	}
+
+	p := make(ShredPattern, 0, rounds)
+	for i := 0; i < rounds-1; i++ {
+		p = append(p, shredPassRandom)
+	}
+
+	return append(p, shredPassZero)
+}
+
+// ShredDoD522022M returns the three pass pattern described in DoD 5220.22-M:
+// zeros, ones and finally random data.
+func ShredDoD522022M() ShredPattern {
+	return ShredPattern{
+		shredPassZero,
+		{Pattern: []byte{0xff}},
+		shredPassRandom,
+	}
+}
+
+// ShredGutmann returns the 35 pass pattern proposed by Peter Gutmann.
+func ShredGutmann() ShredPattern {
+	p := make(ShredPattern, 0, 35)
+	for i := 0; i < 4; i++ {
+		p = append(p, shredPassRandom)
+	}
+
+	for _, b := range [][]byte{
+		{0x55}, {0xaa}, {0x92, 0x49, 0x24}, {0x49, 0x24, 0x92}, {0x24, 0x92, 0x49},
+		{0x00}, {0x11}, {0x22}, {0x33}, {0x44}, {0x55}, {0x66}, {0x77},
+		{0x88}, {0x99}, {0xaa}, {0xbb}, {0xcc}, {0xdd}, {0xee}, {0xff},
+		{0x92, 0x49, 0x24}, {0x49, 0x24, 0x92}, {0x24, 0x92, 0x49},
+		{0x6d, 0xb6, 0xdb}, {0xb6, 0xdb, 0x6d}, {0xdb, 0x6d, 0xb6},
+	} {
+		p = append(p, ShredPass{Pattern: b})
+	}
+
+	for i := 0; i < 4; i++ {
+		p = append(p, shredPassRandom)
+	}
+
+	return p
+}
+
+// Shred overwrite the given file any number of times. Its extended
+// attributes are removed before the file is unlinked.
+func Shred(path string, runs int) error {
+	return ShredContext(context.Background(), path, runs)
+}
+
+// ShredRand is like Shred but draws the data for the random passes from r
+// instead of crypto/rand. This is mostly useful for tests that need
+// deterministic output.
+func ShredRand(path string, rounds int, r io.Reader) error {
+	return shred(context.Background(), path, ShredOpts{Rounds: rounds, Rand: r})
+}
+
+// ShredContext is like Shred but aborts as soon as the context is canceled.
+// The context is checked every few MB, so even very large files can be
+// interrupted quickly. On cancellation the file handle is closed and
+// ctx.Err() is returned. The file is NOT removed in this case and it may
+// have been partially overwritten.
+func ShredContext(ctx context.Context, path string, runs int) error {
+	return shred(ctx, path, ShredOpts{Rounds: runs})
+}
+
+// ShredWithProgress is like Shred but reports the progress of each pass to cb.
+// cb receives the current round (starting at 1), the total number of rounds
+// and the number of bytes written in this round so far. It is invoked every
+// few MB and at least once at the end of each round, even for empty files.
+// cb may be nil.
+func ShredWithProgress(path string, runs int, cb func(round, totalRounds int, bytesWritten, totalBytes int64)) error {
+	return shred(context.Background(), path, ShredOpts{Rounds: runs, Progress: cb})
+}
+
+// ShredWith overwrites the given file using each pass of the pattern in turn
+// and removes it afterwards.
+func ShredWith(path string, pattern ShredPattern) error {
+	return shred(context.Background(), path, ShredOpts{Pattern: pattern})
+}
+
+// ShredAndTruncate is like Shred but adds another zero pass and truncates
+// the file to zero length before removing it. This way neither the content
+// nor the original length of the file can be recovered from the blocks that
+// used to belong to it.
+func ShredAndTruncate(path string, rounds int) error {
+	return shred(context.Background(), path, ShredOpts{
+		Pattern:  append(ShredRandom(rounds), shredPassZero),
+		Truncate: true,
+	})
+}
+
+// ShredScrub is like Shred but also obscures the name of the file before
+// removing it, see ShredOpts.ScrubName.
+func ShredScrub(path string, rounds int) error {
+	return shred(context.Background(), path, ShredOpts{Rounds: rounds, ScrubName: true})
+}
+
+// ShredFile overwrites the content of an already open file the given number
+// of times like Shred and truncates it to zero length afterwards. fh must be
+// opened for writing. It is neither closed nor removed, that is up to the
+// caller. This avoids reopening the file by name, which might meanwhile refer
+// to a different file.
+func ShredFile(fh *os.File, rounds int) error {
+	return shredHandle(context.Background(), fh, ShredOpts{Rounds: rounds, Truncate: true})
+}
+
+// ShredOpts controls the behaviour of ShredWithOpts.
+type ShredOpts struct {
+	// Rounds is the number of passes of ShredRandom. It is ignored if
+	// Pattern is set.
+	Rounds int
+	// Pattern is the sequence of passes to apply.
+	Pattern ShredPattern
+	// Progress is invoked as described for ShredWithProgress.
+	Progress func(round, totalRounds int, bytesWritten, totalBytes int64)
+	// Truncate the file to zero length after overwriting it.
+	Truncate bool
+	// FailIneffective refuses to shred files on copy-on-write filesystems
+	// and returns ErrShredIneffective instead.
+	FailIneffective bool
+	// FailHardLinks refuses to shred files with more than one hard link
+	// and returns ErrMultipleHardLinks instead. Shredding such a file would
+	// overwrite the content seen through all links but only remove one of
+	// them.
+	FailHardLinks bool
+	// ScrubName renames the file to random names of the same length a few
+	// times before removing it, so the original name is overwritten in the
+	// directory entry.
+	ScrubName bool
+	// BlockSize is the number of bytes written at once. Larger blocks
+	// speed up shredding large files on fast disks. Defaults to 64KiB.
+	BlockSize int
+	// Rand is the source for random passes. Defaults to crypto/rand.
+	Rand io.Reader
+	// ScrubTimestamps sets the access and modification times of the file
+	// to the Unix epoch before removing it, so they can't tell when the
+	// secret was last used. The change time can not be reset without
+	// privileged operations on most systems and is left as is.
+	ScrubTimestamps bool
+	// AllowDangerousPath lets ShredDirWithOpts operate on dangerous paths,
+	// see IsDangerousPath.
+	AllowDangerousPath bool
+}
+
+// ErrShredIneffective is returned if the file lives on a filesystem where
+// overwriting it does not destroy the original content.
+var ErrShredIneffective = fmt.Errorf("shredding is ineffective on copy-on-write filesystems")
+
+// ErrMultipleHardLinks is returned if the file to shred is still referenced
+// by other hard links.
+var ErrMultipleHardLinks = fmt.Errorf("file has multiple hard links")
+
+// ErrFileInUse is returned if the file to shred is opened by another process
+// that prevents writing to it. This can only happen on Windows.
+var ErrFileInUse = fmt.Errorf("file is in use by another process")
+
+// ShredWithOpts shreds the given file according to opts.
+func ShredWithOpts(path string, opts ShredOpts) error {
+	return shred(context.Background(), path, opts)
+}
+
+func (o ShredOpts) pattern() ShredPattern {
+	if o.Pattern != nil {
+		return o.Pattern
+	}
+
+	return ShredRandom(o.Rounds)
+}
+
+func (o ShredOpts) blockSize() int {
+	if o.BlockSize > 0 {
+		return o.BlockSize
+	}
+
+	return shredBlockSize
+}
+
+func (o ShredOpts) rand() io.Reader {
+	if o.Rand != nil {
+		return o.Rand
+	}
+
+	return rand.Reader
+}
+
+func shred(ctx context.Context, path string, opts ShredOpts) error {
+	if err := scrub(ctx, path, opts); err != nil {
+		return err
+	}
+
+	clearXattrs(path)
+
+	if opts.ScrubTimestamps {
+		if err := os.Chtimes(path, shredEpoch, shredEpoch); err != nil {
+			return fmt.Errorf("failed to reset timestamps of %q: %w", path, err)
+		}
+	}
+
+	if opts.ScrubName {
+		path = scrubName(path, shredScrubRenames)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Scrub overwrites the given file like Shred and truncates it to zero
+// length, but does not remove it. The file keeps its inode and permissions,
+// so open handles stay valid.
+func Scrub(path string, rounds int) error {
+	return scrub(context.Background(), path, ShredOpts{Rounds: rounds, Truncate: true})
+}
+
+// scrub overwrites the file at path according to opts without removing it.
+func scrub(ctx context.Context, path string, opts ShredOpts) error {
+	if opts.FailIneffective {
+		cow, err := IsCoWFilesystem(path)
+		if err != nil {
+			return err
+		}
+
+		if cow {
+			return fmt.Errorf("can not shred %q: %w", path, ErrShredIneffective)
+		}
+	}
+
+	if opts.FailHardLinks {
+		n, err := LinkCount(path)
+		if err != nil {
+			return err
+		}
+
+		if n > 1 {
+			return fmt.Errorf("can not shred %q with %d links: %w", path, n, ErrMultipleHardLinks)
+		}
+	}
+
+	if locked, err := IsFileLocked(path); err == nil && locked {
+		return fmt.Errorf("can not shred %q: %w", path, ErrFileInUse)
+	}
+
+	fh, err := os.OpenFile(path, os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %w", path, err)
+	}
+
+	// ignore the error. this is only taking effect if we error out.
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	if err := shredHandle(ctx, fh, opts); err != nil {
+		return err
+	}
+
+	if err := fh.Close(); err != nil {
+		return fmt.Errorf("failed to close file after writing: %w", err)
+	}
+
+	return nil
+}
+
+// scrubName renames the file at path to the given number of random names of
+// the same length in the same directory and returns its final name. Failed
+// renames are ignored, the file keeps its last name then.
+func scrubName(path string, renames int) string {
+	dir := filepath.Dir(path)
+	n := len(filepath.Base(path))
+
+	for i := 0; i < renames; i++ {
+		name := ""
+		for len(name) < n {
+			name += randomSuffix()
+		}
+
+		next := filepath.Join(dir, name[:n])
+
+		// never replace another file
+		if _, err := os.Lstat(next); !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err := os.Rename(path, next); err != nil {
+			debug.Log("failed to rename %s before removing it: %s", path, err)
+
+			continue
+		}
+
+		path = next
+	}
+
+	return path
+}
+
+// shredHandle overwrites the content of an open file and optionally
+// truncates it. It does not close or remove the file.
+func shredHandle(ctx context.Context, fh *os.File, opts ShredOpts) error {
+	if err := overwrite(ctx, fh, opts); err != nil {
+		return err
+	}
+
+	if !opts.Truncate {
+		return nil
+	}
+
+	if err := fh.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate file: %w", err)
+	}
+
+	if err := fh.Sync(); err != nil {
+		return fmt.Errorf("failed to sync to disk: %w", err)
+	}
+
+	return nil
+}
+
+// overwrite applies every pass of the pattern to the whole file and syncs
+// it to disk after each pass.
+func overwrite(ctx context.Context, fh *os.File, opts ShredOpts) error {
+	pattern := opts.pattern()
+
+	cb := opts.Progress
+	if cb == nil {
+		cb = func(int, int, int64, int64) {}
+	}
+
+	r := opts.rand()
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file %q: %w", fh.Name(), err)
+	}
+
+	flen := fi.Size()
+	buf := make([]byte, opts.blockSize())
+
+	for i, pass := range pattern {
+		if err := ctx.Err(); err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		if _, err := fh.Seek(0, 0); err != nil {
+			return fmt.Errorf("failed to seek to 0,0: %w", err)
+		}
+
+		var written, lastCheck int64
+
+		for {
+			// end of file
+			if written >= flen {
+				break
+			}
+
+			if written-lastCheck >= shredCheckInterval {
+				if err := ctx.Err(); err != nil {
+					return err //nolint:wrapcheck
+				}
+
+				cb(i+1, len(pattern), written, flen)
+
+				lastCheck = written
+			}
+
+			chunk := buf[0:min(flen-written, int64(len(buf)))]
+			if err := pass.fill(chunk, written, r); err != nil {
+				return err
+			}
+
+			n, err := fh.Write(chunk)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					return fmt.Errorf("failed to write to file: %w", err)
+				}
+				// end of file, should not happen
+				break
+			}
+
+			written += int64(n)
+		}
+		// if we fail to sync the written blocks to disk it'd be pointless
+		// do any further loops
+		if err := fh.Sync(); err != nil {
+			return fmt.Errorf("failed to sync to disk: %w", err)
+		}
+
+		cb(i+1, len(pattern), written, flen)
+	}
+
+	return nil
+}
+
+// fill fills buf with the data for this pass, assuming buf will be written
+// at the given offset of the file. Random data is read from r.
+func (p ShredPass) fill(buf []byte, offset int64, r io.Reader) error {
+	if p.Random || len(p.Pattern) < 1 {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return fmt.Errorf("failed to read random data: %w", err)
+		}
+
+		return nil
+	}
+
+	o := int(offset % int64(len(p.Pattern)))
+	for i := range buf {
+		buf[i] = p.Pattern[(o+i)%len(p.Pattern)]
+	}
+
+	return nil
+}
+
+// ShredForce is like Shred but makes read-only files writeable before
+// shredding them. If the file can still not be shredded its original
+// permissions are restored.
+func ShredForce(path string, rounds int) error {
+	fh, err := os.OpenFile(path, os.O_WRONLY, 0o600)
+	if err == nil {
+		_ = fh.Close()
+
+		return Shred(path, rounds)
+	}
+
+	if !errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("failed to open file %q: %w", path, err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file %q: %w", path, err)
+	}
+
+	debug.Log("making %s writeable before shredding", path)
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("failed to make %q writeable: %w", path, err)
+	}
+
+	if err := Shred(path, rounds); err != nil {
+		_ = os.Chmod(path, fi.Mode().Perm())
+
+		return err
+	}
+
+	return nil
+}
+
+// ShredDir recursively shreds every regular file below path and removes the
+// emptied directories on the way back up, including path itself.
+// Symlinks are removed but never followed, so their targets are left untouched.
+// Errors for individual entries do not abort the operation, they are collected
+// and returned once the whole tree has been processed. ErrDangerousPath is
+// returned if path is a dangerous path, see IsDangerousPath.
+func ShredDir(path string, rounds int) error {
+	return ShredDirWithOpts(path, ShredOpts{Rounds: rounds})
+}
+
+// ShredDirWithOpts is like ShredDir but shreds each file according to opts.
+func ShredDirWithOpts(path string, opts ShredOpts) error {
+	if err := checkDangerous("shred", path, opts.AllowDangerousPath); err != nil {
+		return err
+	}
+
+	_, err := shredDirRoot(path, opts, false)
+
+	return err
+}
+
+// ShredDirDryRun returns the files and directories that ShredDir would
+// remove, in the order they would be removed, without touching anything.
+func ShredDirDryRun(path string) ([]string, error) {
+	return shredDirRoot(path, ShredOpts{}, true)
+}
+
+func shredDirRoot(path string, opts ShredOpts, dryRun bool) ([]string, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("not a directory: %q", path)
+	}
+
+	var removed []string
+	err = shredDir(path, opts, dryRun, &removed)
+
+	return removed, err
+}
+
+func shredDir(path string, opts ShredOpts, dryRun bool, removed *[]string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("failed to read dir %q: %w", path, err)
+	}
+
+	var result error
+
+	remove := func(fp string, fn func(string) error) {
+		if !dryRun {
+			if err := fn(fp); err != nil {
+				result = multierror.Append(result, err)
+
+				return
+			}
+		}
+
+		*removed = append(*removed, fp)
+	}
+
+	for _, e := range entries {
+		fp := filepath.Join(path, e.Name())
+
+		switch {
+		case e.IsDir():
+			if err := shredDir(fp, opts, dryRun, removed); err != nil {
+				result = multierror.Append(result, err)
+			}
+		case e.Type().IsRegular():
+			remove(fp, func(fp string) error {
+				return shred(context.Background(), fp, opts)
+			})
+		default:
+			// symlinks, fifos, sockets, etc. have no content we could
+			// overwrite. just unlink them without following.
+			remove(fp, func(fp string) error {
+				if err := os.Remove(fp); err != nil {
+					return fmt.Errorf("failed to remove %q: %w", fp, err)
+				}
+
+				return nil
+			})
+		}
+	}
+
+	// something below is still left, so removing the dir would fail anyway
+	if result != nil {
+		return result
+	}
+
+	if !dryRun {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove dir %q: %w", path, err)
+		}
+	}
+
+	*removed = append(*removed, path)
+
+	return nil
+}
+
+// ShredBatch shreds all given files using up to concurrency workers.
+// A failure to shred one file does not stop the others, all errors are
+// collected and returned at the end.
+func ShredBatch(paths []string, rounds, concurrency int) error {
+	return ShredBatchContext(context.Background(), paths, rounds, concurrency)
+}
+
+// ShredBatchContext is like ShredBatch but stops picking up new files once
+// the context is canceled. Files that are being shredded at that point are
+// aborted as described for ShredContext.
+func ShredBatchContext(ctx context.Context, paths []string, rounds, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var mu sync.Mutex
+
+	var result error
+
+	work := make(chan string)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for path := range work {
+				if err := ShredContext(ctx, path, rounds); err != nil {
+					mu.Lock()
+					result = multierror.Append(result, fmt.Errorf("failed to shred %q: %w", path, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	var canceled error
+
+loop:
+	for _, path := range paths {
+		select {
+		case work <- path:
+		case <-ctx.Done():
+			canceled = ctx.Err()
+
+			break loop
+		}
+	}
+
+	close(work)
+	wg.Wait()
+
+	if canceled != nil {
+		result = multierror.Append(result, canceled)
+	}
+
+	return result
+}
+
+func min(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,601 @@
+package fsutil
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShred(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	// test successful shread
+	fh, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE, 0o644)
+	assert.NoError(t, err)
+
+	buf := make([]byte, 1024)
+	for i := 0; i < 10*1024; i++ {
+		_, _ = rand.Read(buf)
+		_, _ = fh.Write(buf)
+	}
+
+	require.NoError(t, fh.Close())
+	assert.NoError(t, Shred(fn, 8))
+	assert.Equal(t, false, IsFile(fn))
+
+	// test failed
+	fh, err = os.OpenFile(fn, os.O_WRONLY|os.O_CREATE, 0o400)
+	assert.NoError(t, err)
+
+	buf = make([]byte, 1024)
+	for i := 0; i < 10*1024; i++ {
+		_, _ = rand.Read(buf)
+		_, _ = fh.Write(buf)
+	}
+
+	require.NoError(t, fh.Close())
+	assert.Error(t, Shred(fn, 8))
+	assert.Equal(t, true, IsFile(fn))
+}
+
+func TestShredDir(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	outside := filepath.Join(tempdir, "outside")
+	require.NoError(t, os.WriteFile(outside, []byte("keep me"), 0o600))
+
+	root := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "foo", "bar"), 0o700))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "empty"), 0o700))
+
+	for _, fn := range []string{
+		filepath.Join(root, "top.gpg"),
+		filepath.Join(root, "foo", "one.gpg"),
+		filepath.Join(root, "foo", "bar", "two.gpg"),
+	} {
+		require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+	}
+
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "foo", "link")))
+
+	dry, err := ShredDirDryRun(root)
+	require.NoError(t, err)
+	assert.Equal(t, true, IsFile(filepath.Join(root, "foo", "bar", "two.gpg")))
+	assert.Equal(t, true, IsSymlink(filepath.Join(root, "foo", "link")))
+	assert.Equal(t, []string{
+		filepath.Join(root, "empty"),
+		filepath.Join(root, "foo", "bar", "two.gpg"),
+		filepath.Join(root, "foo", "bar"),
+		filepath.Join(root, "foo", "link"),
+		filepath.Join(root, "foo", "one.gpg"),
+		filepath.Join(root, "foo"),
+		filepath.Join(root, "top.gpg"),
+		root,
+	}, dry)
+
+	removed, err := shredDirRoot(root, ShredOpts{Rounds: 2}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, dry, removed)
+	assert.False(t, IsDir(root))
+
+	// the symlink target must not be touched
+	buf, err := os.ReadFile(outside)
+	require.NoError(t, err)
+	assert.Equal(t, "keep me", string(buf))
+
+	assert.Error(t, ShredDir(outside, 2))
+	assert.Error(t, ShredDir(filepath.Join(tempdir, "non-existing"), 2))
+
+	_, err = ShredDirDryRun(outside)
+	assert.Error(t, err)
+}
+
+func TestShredContext(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, make([]byte, 8*1024*1024), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, ShredContext(ctx, fn, 8), context.Canceled)
+	assert.Equal(t, true, IsFile(fn))
+
+	assert.NoError(t, ShredContext(context.Background(), fn, 2))
+	assert.Equal(t, false, IsFile(fn))
+}
+
+func TestShredWithProgress(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	type call struct {
+		round, total int
+		written, len int64
+	}
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, make([]byte, 2048), 0o600))
+
+	var calls []call
+	assert.NoError(t, ShredWithProgress(fn, 3, func(round, total int, written, flen int64) {
+		calls = append(calls, call{round, total, written, flen})
+	}))
+	assert.Equal(t, []call{{1, 3, 2048, 2048}, {2, 3, 2048, 2048}, {3, 3, 2048, 2048}}, calls)
+	assert.Equal(t, false, IsFile(fn))
+
+	// empty files must still report completion
+	require.NoError(t, os.WriteFile(fn, nil, 0o600))
+
+	calls = nil
+	assert.NoError(t, ShredWithProgress(fn, 1, func(round, total int, written, flen int64) {
+		calls = append(calls, call{round, total, written, flen})
+	}))
+	assert.Equal(t, []call{{1, 1, 0, 0}}, calls)
+
+	// nil callbacks are fine
+	require.NoError(t, os.WriteFile(fn, []byte("foo"), 0o600))
+	assert.NoError(t, ShredWithProgress(fn, 1, nil))
+}
+
+func TestShredPatterns(t *testing.T) {
+	t.Parallel()
+
+	assert.Len(t, ShredRandom(0), 0)
+	assert.Len(t, ShredRandom(8), 8)
+	assert.Equal(t, ShredPass{Pattern: []byte{0x00}}, ShredRandom(8)[7])
+	assert.Len(t, ShredDoD522022M(), 3)
+	assert.Len(t, ShredGutmann(), 35)
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, make([]byte, 2500), 0o600))
+
+	for _, pattern := range [][]byte{
+		{0xff},
+		{0x92, 0x49, 0x24},
+	} {
+		fh, err := os.OpenFile(fn, os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+		require.NoError(t, overwrite(context.Background(), fh, ShredOpts{Pattern: ShredPattern{{Pattern: pattern}}}))
+		require.NoError(t, fh.Close())
+
+		buf, err := os.ReadFile(fn)
+		require.NoError(t, err)
+		require.Len(t, buf, 2500)
+
+		for i, b := range buf {
+			// use require, a mismatch would otherwise produce thousands of failures
+			require.Equal(t, pattern[i%len(pattern)], b, "byte %d", i)
+		}
+	}
+
+	var rounds int
+	assert.NoError(t, ShredWith(fn, ShredDoD522022M()))
+	assert.Equal(t, false, IsFile(fn))
+
+	require.NoError(t, os.WriteFile(fn, make([]byte, 2500), 0o600))
+	assert.NoError(t, shred(context.Background(), fn, ShredOpts{
+		Pattern: ShredGutmann(),
+		Progress: func(round, total int, _, _ int64) {
+			rounds = round
+			assert.Equal(t, 35, total)
+		},
+	}))
+	assert.Equal(t, 35, rounds)
+	assert.Equal(t, false, IsFile(fn))
+}
+
+func TestShredAndTruncate(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte("secret content"), 0o600))
+
+	// the file must be empty before it is unlinked, a second link to the
+	// same inode lets us check that after it has been removed
+	peek := filepath.Join(tempdir, "peek")
+	require.NoError(t, os.Link(fn, peek))
+
+	assert.NoError(t, ShredAndTruncate(fn, 2))
+	assert.Equal(t, false, IsFile(fn))
+
+	fi, err := os.Stat(peek)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), fi.Size())
+
+	// a plain shred keeps the length
+	require.NoError(t, os.WriteFile(peek, []byte("secret content"), 0o600))
+	require.NoError(t, os.Link(peek, fn))
+	require.NoError(t, Shred(fn, 1))
+
+	fi, err = os.Stat(peek)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("secret content")), fi.Size())
+}
+
+func TestShredForce(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o400))
+
+	assert.NoError(t, ShredForce(fn, 2))
+	assert.Equal(t, false, IsFile(fn))
+
+	assert.Error(t, ShredForce(fn, 2))
+}
+
+func TestShredBatch(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	paths := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		fn := filepath.Join(tempdir, fmt.Sprintf("file-%02d", i))
+		require.NoError(t, os.WriteFile(fn, []byte(fn), 0o600))
+
+		paths = append(paths, fn)
+	}
+
+	require.NoError(t, ShredBatch(paths, 2, 4))
+
+	entries, err := os.ReadDir(tempdir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// errors do not stop the other files
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, []byte(fn), 0o600))
+
+	err = ShredBatch([]string{filepath.Join(tempdir, "non-existing"), fn}, 1, 0)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Equal(t, false, IsFile(fn))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, os.WriteFile(fn, []byte(fn), 0o600))
+	assert.ErrorIs(t, ShredBatchContext(ctx, []string{fn}, 1, 4), context.Canceled)
+	assert.Equal(t, true, IsFile(fn))
+}
+
+func TestShredScrub(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "bank-account.gpg")
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+
+	next := scrubName(fn, 5)
+	assert.NotEqual(t, fn, next)
+	assert.Equal(t, len(fn), len(next))
+	assert.Equal(t, false, IsFile(fn))
+	assert.Equal(t, true, IsFile(next))
+
+	// failed renames are tolerated
+	assert.Equal(t, filepath.Join(tempdir, "non-existing"), scrubName(filepath.Join(tempdir, "non-existing"), 2))
+
+	// no renames at all
+	assert.Equal(t, next, scrubName(next, 0))
+
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+	require.NoError(t, ShredScrub(fn, 2))
+	require.NoError(t, ShredScrub(next, 2))
+
+	entries, err := os.ReadDir(tempdir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShredFile(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "plaintext")
+	fh, err := os.OpenFile(fn, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	require.NoError(t, err)
+
+	_, err = fh.WriteString("secret")
+	require.NoError(t, err)
+
+	require.NoError(t, ShredFile(fh, 2))
+
+	fi, err := fh.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), fi.Size())
+
+	require.NoError(t, fh.Close())
+	assert.Equal(t, true, IsFile(fn))
+	require.NoError(t, os.Remove(fn))
+
+	// a read-only handle can not be shredded
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+	fh, err = os.Open(fn)
+	require.NoError(t, err)
+
+	assert.Error(t, ShredFile(fh, 1))
+	require.NoError(t, fh.Close())
+}
+
+func TestScrub(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret")
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+
+	require.NoError(t, Scrub(fn, 2))
+
+	fi, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), fi.Size())
+
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	}
+
+	assert.Error(t, Scrub(filepath.Join(tempdir, "non-existing"), 2))
+}
+
+func TestShredBlockSize(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, shredBlockSize, ShredOpts{}.blockSize())
+	assert.Equal(t, shredBlockSize, ShredOpts{BlockSize: -1}.blockSize())
+	assert.Equal(t, 7, ShredOpts{BlockSize: 7}.blockSize())
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	pattern := []byte{0x92, 0x49, 0x24}
+
+	// block sizes not aligned to the pattern or the file size must still
+	// produce a continuous pattern
+	for _, bs := range []int{1, 7, 1000, 1 << 20} {
+		require.NoError(t, os.WriteFile(fn, make([]byte, 2500), 0o600))
+
+		fh, err := os.OpenFile(fn, os.O_WRONLY, 0o600)
+		require.NoError(t, err)
+		require.NoError(t, overwrite(context.Background(), fh, ShredOpts{
+			Pattern:   ShredPattern{{Pattern: pattern}},
+			BlockSize: bs,
+		}))
+		require.NoError(t, fh.Close())
+
+		buf, err := os.ReadFile(fn)
+		require.NoError(t, err)
+		require.Len(t, buf, 2500)
+
+		for i, b := range buf {
+			require.Equal(t, pattern[i%len(pattern)], b, "block size %d, byte %d", bs, i)
+		}
+	}
+
+	assert.NoError(t, ShredWithOpts(fn, ShredOpts{Rounds: 2, BlockSize: 1 << 20}))
+	assert.Equal(t, false, IsFile(fn))
+}
+
+func BenchmarkShred4KiB(b *testing.B) {
+	benchmarkShredBlockSize(b, 4*1024)
+}
+
+func BenchmarkShred1MiB(b *testing.B) {
+	benchmarkShredBlockSize(b, 1024*1024)
+}
+
+func benchmarkShredBlockSize(b *testing.B, blockSize int) {
+	b.Helper()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(b, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	size := 16 * 1024 * 1024
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(b, os.WriteFile(fn, make([]byte, size), 0o600))
+
+	fh, err := os.OpenFile(fn, os.O_WRONLY, 0o600)
+	require.NoError(b, err)
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	opts := ShredOpts{Rounds: 1, BlockSize: blockSize}
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := overwrite(context.Background(), fh, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// patternReader endlessly repeats a fixed byte pattern and counts the bytes
+// read from it.
+type patternReader struct {
+	pattern []byte
+	read    int
+}
+
+func (r *patternReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.pattern[(r.read+i)%len(r.pattern)]
+	}
+
+	r.read += len(p)
+
+	return len(p), nil
+}
+
+func TestShredRand(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "file")
+	require.NoError(t, os.WriteFile(fn, make([]byte, 2500), 0o600))
+
+	// a single random pass must write exactly the data from the source
+	r := &patternReader{pattern: []byte("gopass")}
+	fh, err := os.OpenFile(fn, os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	require.NoError(t, overwrite(context.Background(), fh, ShredOpts{
+		Pattern:   ShredPattern{shredPassRandom},
+		BlockSize: 1000,
+		Rand:      r,
+	}))
+	require.NoError(t, fh.Close())
+	assert.Equal(t, 2500, r.read)
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	require.Len(t, buf, 2500)
+
+	for i, b := range buf {
+		require.Equal(t, r.pattern[i%len(r.pattern)], b, "byte %d", i)
+	}
+
+	// all but the last (zero) pass draw from the source
+	r = &patternReader{pattern: []byte{0x42}}
+	require.NoError(t, ShredRand(fn, 4, r))
+	assert.Equal(t, 3*2500, r.read)
+	assert.Equal(t, false, IsFile(fn))
+
+	// an exhausted source must not be silently ignored
+	require.NoError(t, os.WriteFile(fn, make([]byte, 2500), 0o600))
+	assert.Error(t, ShredRand(fn, 2, strings.NewReader("short")))
+	assert.Equal(t, true, IsFile(fn))
+}
+
+func TestShredScrubTimestamps(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret")
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+
+	// timestamps belong to the inode, so they are visible through another
+	// link after the file has been removed
+	peek := filepath.Join(tempdir, "peek")
+	require.NoError(t, os.Link(fn, peek))
+
+	require.NoError(t, ShredWithOpts(fn, ShredOpts{Rounds: 1, ScrubTimestamps: true}))
+	assert.Equal(t, false, IsFile(fn))
+
+	fi, err := os.Stat(peek)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(0, 0).Unix(), fi.ModTime().Unix())
+
+	// without the option the timestamps are kept
+	require.NoError(t, os.Link(peek, fn))
+	require.NoError(t, Shred(fn, 1))
+
+	fi, err = os.Stat(peek)
+	require.NoError(t, err)
+	assert.NotEqual(t, time.Unix(0, 0).Unix(), fi.ModTime().Unix())
+}
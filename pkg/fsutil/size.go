@@ -0,0 +1,65 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileSize returns the size of a regular file in bytes.
+func FileSize(path string) (int64, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		return 0, fmt.Errorf("not a regular file: %q", path)
+	}
+
+	return fi.Size(), nil
+}
+
+// DirSize returns the combined size of all regular files below path.
+// Symlinks are not followed and files that disappear while walking the
+// tree are skipped.
+func DirSize(path string) (int64, error) {
+	if _, err := os.Stat(path); err != nil {
+		return 0, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	var size int64
+
+	if err := filepath.WalkDir(path, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if fp != path && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err //nolint:wrapcheck
+		}
+
+		size += fi.Size()
+
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("failed to walk %q: %w", path, err)
+	}
+
+	return size, nil
+}
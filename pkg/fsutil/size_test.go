@@ -0,0 +1,60 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSize(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "foo")
+	require.NoError(t, os.WriteFile(fn, []byte("foobar"), 0o600))
+
+	size, err := FileSize(fn)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), size)
+
+	_, err = FileSize(tempdir)
+	assert.Error(t, err)
+
+	_, err = FileSize(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
+
+func TestDirSize(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempdir, "foo", "bar"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "a"), []byte("foo"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "foo", "b"), []byte("foobar"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "foo", "bar", "c"), []byte("x"), 0o600))
+
+	// must not be followed
+	require.NoError(t, os.Symlink(filepath.Join(tempdir, "foo"), filepath.Join(tempdir, "link")))
+
+	size, err := DirSize(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), size)
+
+	_, err = DirSize(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
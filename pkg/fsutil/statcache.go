@@ -0,0 +1,92 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// StatCache caches the results of stat calls for IsDir, IsFile and Exists.
+// The entries never expire, so a StatCache should only live as long as a
+// single operation on the store, and Invalidate must be called for every
+// path that is modified in the meantime. It's safe for concurrent use.
+type StatCache struct {
+	mu      sync.Mutex
+	entries map[string]statEntry
+}
+
+type statEntry struct {
+	fi  os.FileInfo
+	err error
+}
+
+// NewStatCache returns a new, empty StatCache.
+func NewStatCache() *StatCache {
+	return &StatCache{
+		entries: map[string]statEntry{},
+	}
+}
+
+// IsDir is like IsDir but uses cached results.
+func (c *StatCache) IsDir(path string) bool {
+	fi, err := c.stat(path)
+
+	return err == nil && fi.IsDir()
+}
+
+// IsFile is like IsFile but uses cached results.
+func (c *StatCache) IsFile(path string) bool {
+	fi, err := c.stat(path)
+
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// Exists is like Exists but uses cached results.
+func (c *StatCache) Exists(path string) bool {
+	_, err := c.stat(path)
+
+	return err == nil
+}
+
+// Invalidate removes path and everything below it from the cache.
+func (c *StatCache) Invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path = filepath.Clean(path)
+	prefix := path + string(filepath.Separator)
+
+	for p := range c.entries {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(c.entries, p)
+		}
+	}
+}
+
+// stat returns the cached result of stat'ing path. Symlinks are followed,
+// like in IsDir and IsFile.
+func (c *StatCache) stat(path string) (os.FileInfo, error) {
+	path = filepath.Clean(path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]statEntry{}
+	}
+
+	if e, found := c.entries[path]; found {
+		return e.fi, e.err
+	}
+
+	// most entries are not symlinks, so one Lstat is usually enough
+	fi, err := os.Lstat(fixLongPath(path))
+	if err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		fi, err = os.Stat(fixLongPath(path))
+	}
+
+	c.entries[path] = statEntry{fi: fi, err: err}
+
+	return fi, err //nolint:wrapcheck
+}
@@ -0,0 +1,89 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatCache(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	dir := filepath.Join(tempdir, "dir")
+	fn := filepath.Join(dir, "file")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+	require.NoError(t, os.WriteFile(fn, []byte("foo"), 0o600))
+	require.NoError(t, os.Symlink(dir, filepath.Join(tempdir, "link")))
+
+	c := NewStatCache()
+	assert.Equal(t, true, c.IsDir(dir))
+	assert.Equal(t, false, c.IsFile(dir))
+	assert.Equal(t, true, c.IsFile(fn))
+	assert.Equal(t, true, c.Exists(fn))
+	assert.Equal(t, true, c.IsDir(filepath.Join(tempdir, "link")))
+	assert.Equal(t, false, c.Exists(filepath.Join(tempdir, "non-existing")))
+
+	// results are cached until invalidated
+	require.NoError(t, os.RemoveAll(dir))
+	assert.Equal(t, true, c.IsDir(dir))
+	assert.Equal(t, true, c.IsFile(fn))
+
+	c.Invalidate(dir)
+	assert.Equal(t, false, c.IsDir(dir))
+	assert.Equal(t, false, c.IsFile(fn))
+	assert.Equal(t, false, c.Exists(fn))
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "non-existing"), []byte("foo"), 0o600))
+	assert.Equal(t, false, c.Exists(filepath.Join(tempdir, "non-existing")))
+	c.Invalidate(filepath.Join(tempdir, "non-existing"))
+	assert.Equal(t, true, c.Exists(filepath.Join(tempdir, "non-existing")))
+
+	// the zero value is usable as well
+	var zc StatCache
+	assert.Equal(t, true, zc.IsDir(tempdir))
+}
+
+func benchmarkStat(b *testing.B, isDir func(string) bool) {
+	b.Helper()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(b, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	paths := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		p := filepath.Join(tempdir, "dir", string(rune('a'+i%26)))
+		require.NoError(b, os.MkdirAll(p, 0o700))
+
+		paths = append(paths, p)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, p := range paths {
+			_ = isDir(p)
+		}
+	}
+}
+
+func BenchmarkIsDir(b *testing.B) {
+	benchmarkStat(b, IsDir)
+}
+
+func BenchmarkStatCacheIsDir(b *testing.B) {
+	benchmarkStat(b, NewStatCache().IsDir)
+}
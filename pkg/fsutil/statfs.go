@@ -0,0 +1,27 @@
+package fsutil
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// existingAncestor returns the closest parent of path (or path itself) that does
+// exist. Any error other than fs.ErrNotExist stops the search, so invalid
+// paths are returned as is.
+func existingAncestor(path string) string {
+	p := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(p); err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return p
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return p
+		}
+
+		p = parent
+	}
+}
@@ -0,0 +1,40 @@
+//go:build openbsd
+// +build openbsd
+
+package fsutil
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// DiskFree returns the number of bytes available to the current user on
+// the filesystem containing path. path does not need to exist.
+func DiskFree(path string) (uint64, error) {
+	dir := existingAncestor(path)
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("failed to statfs %q: %w", dir, err)
+	}
+
+	return uint64(st.F_bavail) * uint64(st.F_bsize), nil
+}
+
+// FreeInodes returns the number of free inodes on the filesystem containing
+// path. path does not need to exist.
+func FreeInodes(path string) (uint64, error) {
+	dir := existingAncestor(path)
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("failed to statfs %q: %w", dir, err)
+	}
+
+	if st.F_files == 0 {
+		return 0, ErrNotSupported
+	}
+
+	return st.F_ffree, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !openbsd && !windows
+// +build !linux,!darwin,!freebsd,!openbsd,!windows
+
+package fsutil
+
+// DiskFree is not supported on this platform.
+func DiskFree(path string) (uint64, error) {
+	return 0, ErrNotSupported
+}
+
+// FreeInodes is not supported on this platform.
+func FreeInodes(path string) (uint64, error) {
+	return 0, ErrNotSupported
+}
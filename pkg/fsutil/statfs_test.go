@@ -0,0 +1,79 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskFree(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	free, err := DiskFree(tempdir)
+	require.NoError(t, err)
+	assert.NotZero(t, free)
+
+	// the path does not need to exist
+	free, err = DiskFree(filepath.Join(tempdir, "non-existing", "file"))
+	require.NoError(t, err)
+	assert.NotZero(t, free)
+
+	_, err = DiskFree(string([]byte{0}))
+	assert.Error(t, err)
+}
+
+func TestFreeInodes(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	free, err := FreeInodes(tempdir)
+	if runtime.GOOS == "windows" {
+		assert.ErrorIs(t, err, ErrNotSupported)
+
+		return
+	}
+
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip("filesystem has no fixed number of inodes")
+	}
+
+	require.NoError(t, err)
+	assert.NotZero(t, free)
+
+	_, err = FreeInodes(string([]byte{0}))
+	assert.Error(t, err)
+}
+
+func TestExistingAncestor(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	tempdir = filepath.Clean(tempdir)
+
+	assert.Equal(t, tempdir, existingAncestor(tempdir))
+	assert.Equal(t, tempdir, existingAncestor(filepath.Join(tempdir, "foo", "bar")))
+}
@@ -0,0 +1,46 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package fsutil
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// DiskFree returns the number of bytes available to the current user on
+// the filesystem containing path. path does not need to exist.
+func DiskFree(path string) (uint64, error) {
+	dir := existingAncestor(path)
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("failed to statfs %q: %w", dir, err)
+	}
+
+	return uint64(st.Bavail) * uint64(st.Bsize), nil //nolint:unconvert
+}
+
+// FreeInodes returns the number of free inodes on the filesystem containing
+// path. path does not need to exist. It returns ErrNotSupported for
+// filesystems without a fixed number of inodes, e.g. btrfs.
+func FreeInodes(path string) (uint64, error) {
+	dir := existingAncestor(path)
+
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, fmt.Errorf("failed to statfs %q: %w", dir, err)
+	}
+
+	if st.Files == 0 {
+		return 0, ErrNotSupported
+	}
+
+	// signed on FreeBSD, where it can be negative for the superuser reserve
+	if int64(st.Ffree) < 0 { //nolint:unconvert
+		return 0, nil
+	}
+
+	return uint64(st.Ffree), nil //nolint:unconvert
+}
@@ -0,0 +1,34 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// DiskFree returns the number of bytes available to the current user on
+// the volume containing path. path does not need to exist.
+func DiskFree(path string) (uint64, error) {
+	dir := existingAncestor(path)
+
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, fmt.Errorf("invalid path %q: %w", dir, err)
+	}
+
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, &total, &totalFree); err != nil {
+		return 0, fmt.Errorf("failed to get free disk space of %q: %w", dir, err)
+	}
+
+	return free, nil
+}
+
+// FreeInodes is not supported on Windows, NTFS has no fixed number of
+// inodes.
+func FreeInodes(path string) (uint64, error) {
+	return 0, ErrNotSupported
+}
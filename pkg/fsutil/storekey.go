@@ -0,0 +1,66 @@
+package fsutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSecretExt is the file extension of secrets in a gpg store.
+const DefaultSecretExt = ".gpg"
+
+// StoreKey returns the key of the secret at abspath in the store at root,
+// i.e. the slash separated path relative to root without DefaultSecretExt.
+// It returns ErrOutsideRoot if abspath is not below root. Both paths are
+// cleaned with CleanPath but symlinks are not resolved.
+func StoreKey(root, abspath string) (string, error) {
+	return StoreKeyExt(root, abspath, DefaultSecretExt)
+}
+
+// StoreKeyExt is like StoreKey but removes the given extension instead,
+// e.g. ".age". An empty ext keeps the file name as is.
+func StoreKeyExt(root, abspath, ext string) (string, error) {
+	r := CleanPath(root)
+	p := CleanPath(abspath)
+
+	if p == r || !isLexicalSubPath(r, p) {
+		return "", fmt.Errorf("%q is not below %q: %w", abspath, root, ErrOutsideRoot)
+	}
+
+	rel, err := filepath.Rel(r, p)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path of %q: %w", abspath, err)
+	}
+
+	return strings.TrimSuffix(ToSlash(rel), secretExt(ext)), nil
+}
+
+// KeyPath is the inverse of StoreKeyExt. It returns the path of the file
+// holding the secret key in the store at root. It does not check whether
+// the key escapes root, use ConfinePath for untrusted keys.
+func KeyPath(root, key, ext string) string {
+	return filepath.Join(root, FromSlash(key)) + secretExt(ext)
+}
+
+// ToSlash converts the OS specific separators in path to the forward slashes
+// used by store keys. It is meant for logical keys only, a backslash is a
+// valid character in file names on Unix and is therefore only converted on
+// Windows.
+func ToSlash(path string) string {
+	return filepath.ToSlash(path)
+}
+
+// FromSlash is the inverse of ToSlash and converts a store key to a path
+// using the OS specific separator.
+func FromSlash(key string) string {
+	return filepath.FromSlash(key)
+}
+
+// secretExt adds the leading dot to ext if it's missing.
+func secretExt(ext string) string {
+	if ext == "" || strings.HasPrefix(ext, ".") {
+		return ext
+	}
+
+	return "." + ext
+}
@@ -0,0 +1,76 @@
+package fsutil
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreKey(t *testing.T) {
+	t.Parallel()
+
+	root := CleanPath(filepath.Join("store", "root"))
+
+	for _, tc := range []struct {
+		key string
+		ext string
+	}{
+		{key: "foo", ext: ".gpg"},
+		{key: "foo/bar/baz", ext: ".gpg"},
+		{key: "with space/and more spaces", ext: ".gpg"},
+		{key: "web/example.com", ext: ".gpg"},
+		{key: "foo/bar", ext: ".age"},
+		{key: "foo/bar", ext: "age"},
+		{key: "foo/bar.txt", ext: ""},
+	} {
+		p := KeyPath(root, tc.key, tc.ext)
+
+		key, err := StoreKeyExt(root, p, tc.ext)
+		require.NoError(t, err, tc.key)
+		assert.Equal(t, tc.key, key)
+	}
+
+	key, err := StoreKey(root, filepath.Join(root, "foo", "bar.gpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo/bar", key)
+
+	assert.Equal(t, filepath.Join(root, "foo", "bar.gpg"), KeyPath(root, "foo/bar", DefaultSecretExt))
+
+	// only the configured extension is removed
+	key, err = StoreKey(root, filepath.Join(root, "foo", "bar.age"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo/bar.age", key)
+
+	for _, p := range []string{
+		root,
+		filepath.Dir(root),
+		filepath.Join(filepath.Dir(root), "root2", "foo.gpg"),
+		filepath.Join(root, "..", "foo.gpg"),
+	} {
+		_, err := StoreKey(root, p)
+		assert.ErrorIs(t, err, ErrOutsideRoot, p)
+	}
+}
+
+func TestToSlash(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "foo/bar/baz", ToSlash("foo/bar/baz"))
+	assert.Equal(t, "foo/bar/baz", ToSlash(filepath.Join("foo", "bar", "baz")))
+	assert.Equal(t, filepath.Join("foo", "bar", "baz"), FromSlash("foo/bar/baz"))
+	assert.Equal(t, "foo/bar", ToSlash(FromSlash("foo/bar")))
+
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, "foo/bar", ToSlash(`foo\bar`))
+		assert.Equal(t, `foo\bar`, FromSlash("foo/bar"))
+
+		return
+	}
+
+	// a backslash is part of the name on Unix
+	assert.Equal(t, `foo\bar`, ToSlash(`foo\bar`))
+	assert.Equal(t, `foo\bar`, FromSlash(`foo\bar`))
+}
@@ -0,0 +1,240 @@
+package fsutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// storeLockName is the name of the lock file created by StoreLock.
+const storeLockName = ".gopass.lock"
+
+// storeLockGrace is the time after which a lock file without a valid owner
+// record is considered stale. The owner writes the record right after
+// creating the file, so an empty file older than that was left by a crash.
+const storeLockGrace = time.Minute
+
+// StoreLock takes an exclusive lock on the whole store at root, e.g. for the
+// duration of a git pull. It blocks until the lock is acquired or the
+// context is canceled and returns a function to release the lock again.
+//
+// The lock is the file .gopass.lock at the store root. It is created
+// exclusively and records the PID, host name and start time of the owner,
+// and it is removed again on unlock. A lock left behind by a process that
+// is gone is broken by the next caller on the same host. Locks held on other
+// hosts are never broken, their owner can not be checked.
+func StoreLock(ctx context.Context, root string) (func() error, error) {
+	path := filepath.Join(root, storeLockName)
+	owner := newStoreLockOwner().String()
+
+	for {
+		ok, err := tryStoreLock(path, owner)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return func() error {
+				return releaseStoreLock(path, owner)
+			}, nil
+		}
+
+		debug.Log("waiting for store lock held by %s", describeStoreLock(path))
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("store %q is locked by %s: %w", root, describeStoreLock(path), ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// tryStoreLock attempts to create the lock file at path once. A stale lock
+// file is broken first. It returns false if the lock is held by someone else.
+func tryStoreLock(path, owner string) (bool, error) {
+	for i := 0; i < 2; i++ {
+		fh, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			return true, writeStoreLock(fh, owner)
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return false, fmt.Errorf("failed to create lock file %q: %w", path, err)
+		}
+
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// released in the meantime
+				continue
+			}
+
+			return false, fmt.Errorf("failed to read lock file %q: %w", path, err)
+		}
+
+		if !isStaleStoreLock(path, buf) {
+			return false, nil
+		}
+
+		breakStoreLock(path, buf)
+	}
+
+	return false, nil
+}
+
+func writeStoreLock(fh *os.File, owner string) error {
+	_, err := fh.WriteString(owner)
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		_ = os.Remove(fh.Name())
+
+		return fmt.Errorf("failed to write lock file %q: %w", fh.Name(), err)
+	}
+
+	return nil
+}
+
+// isStaleStoreLock returns true if the lock file at path with the content
+// buf belongs to a process on this host which is gone.
+func isStaleStoreLock(path string, buf []byte) bool {
+	o, err := parseStoreLockOwner(buf)
+	if err != nil {
+		fi, err := os.Stat(path)
+
+		return err == nil && time.Since(fi.ModTime()) > storeLockGrace
+	}
+
+	if o.host != hostname() {
+		return false
+	}
+
+	// a reused PID keeps the lock alive, it's not broken then but it
+	// does not block forever either since callers pass a context
+	return o.pid != os.Getpid() && !processAlive(o.pid)
+}
+
+// breakStoreLock removes the stale lock file at path. The file is moved
+// aside before and only removed if it still has the stale content. If
+// someone else broke and took the lock in the meantime their lock file is
+// put back. There is a small window in which a third process can take the
+// lock before that, it's logged but can not be prevented without a lock.
+func breakStoreLock(path string, stale []byte) {
+	aside := fmt.Sprintf("%s.stale-%d-%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, aside); err != nil {
+		debug.Log("failed to move stale store lock %s aside: %s", path, err)
+
+		return
+	}
+
+	defer func() {
+		_ = os.Remove(aside)
+	}()
+
+	buf, err := os.ReadFile(aside)
+	if err == nil && bytes.Equal(buf, stale) {
+		debug.Log("broke stale store lock %s held by %s", path, strings.TrimSpace(string(stale)))
+
+		return
+	}
+
+	if err := os.Link(aside, path); err != nil {
+		debug.Log("WARNING: failed to restore store lock %s: %s", path, err)
+	}
+}
+
+// releaseStoreLock removes the lock file at path if it's still owned by
+// owner.
+func releaseStoreLock(path, owner string) error {
+	buf, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read lock file %q: %w", path, err)
+	}
+
+	if string(buf) != owner {
+		return fmt.Errorf("store lock %q was broken by someone else", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove lock file %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// describeStoreLock returns a description of the process holding the lock.
+// It is only informational, the content might be outdated or unreadable.
+func describeStoreLock(path string) string {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return "an unknown process"
+	}
+
+	o, err := parseStoreLockOwner(buf)
+	if err != nil {
+		return "an unknown process"
+	}
+
+	return fmt.Sprintf("pid %d on %s since %s", o.pid, o.host, o.start.Format(time.RFC3339))
+}
+
+type storeLockOwner struct {
+	pid   int
+	host  string
+	start time.Time
+}
+
+func newStoreLockOwner() storeLockOwner {
+	return storeLockOwner{
+		pid:   os.Getpid(),
+		host:  hostname(),
+		start: time.Now().UTC(),
+	}
+}
+
+// String returns the owner record as written to the lock file. The start
+// time has nanoseconds so two records of the same process never match.
+func (o storeLockOwner) String() string {
+	return fmt.Sprintf("%d %s %s\n", o.pid, o.host, o.start.Format(time.RFC3339Nano))
+}
+
+func parseStoreLockOwner(buf []byte) (storeLockOwner, error) {
+	f := strings.Fields(string(buf))
+	if len(f) != 3 {
+		return storeLockOwner{}, fmt.Errorf("invalid lock owner %q", string(buf))
+	}
+
+	pid, err := strconv.Atoi(f[0])
+	if err != nil {
+		return storeLockOwner{}, fmt.Errorf("invalid lock owner pid %q: %w", f[0], err)
+	}
+
+	start, err := time.Parse(time.RFC3339Nano, f[2])
+	if err != nil {
+		return storeLockOwner{}, fmt.Errorf("invalid lock owner start time %q: %w", f[2], err)
+	}
+
+	return storeLockOwner{pid: pid, host: f[1], start: start}, nil
+}
+
+// hostname returns the host name used in lock owner records. It never
+// contains spaces.
+func hostname() string {
+	h, err := os.Hostname()
+	if err != nil || h == "" {
+		return "unknown"
+	}
+
+	return strings.Join(strings.Fields(h), "_")
+}
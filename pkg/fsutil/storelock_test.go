@@ -0,0 +1,99 @@
+package fsutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreLock(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, storeLockName)
+
+	unlock, err := StoreLock(context.Background(), tempdir)
+	require.NoError(t, err)
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(buf), fmt.Sprintf("%d %s ", os.Getpid(), hostname())), string(buf))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = StoreLock(ctx, tempdir)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, unlock())
+	assert.Equal(t, false, Exists(fn))
+
+	unlock, err = StoreLock(context.Background(), tempdir)
+	require.NoError(t, err)
+	require.NoError(t, unlock())
+	assert.Equal(t, false, Exists(fn))
+}
+
+func TestStoreLockStale(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, storeLockName)
+
+	// a process that is gone by the time we try to lock
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+
+	dead := storeLockOwner{pid: cmd.Process.Pid, host: hostname(), start: time.Now().Add(-time.Hour)}
+
+	// a dead process on another host can not be checked
+	remote := dead
+	remote.host = "other-" + remote.host
+	require.NoError(t, os.WriteFile(fn, []byte(remote.String()), 0o600))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = StoreLock(ctx, tempdir)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// a dead process on this host is broken
+	require.NoError(t, os.WriteFile(fn, []byte(dead.String()), 0o600))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	unlock, err := StoreLock(ctx, tempdir)
+	require.NoError(t, err)
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(buf), fmt.Sprintf("%d ", os.Getpid())), string(buf))
+
+	require.NoError(t, unlock())
+
+	// only the lock file itself was ever created
+	entries, err := os.ReadDir(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || aix
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris aix
+
+package fsutil
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive returns true if a process with the given PID exists. A
+// process owned by another user exists as well, it's just not ours to
+// signal.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
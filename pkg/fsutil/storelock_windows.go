@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code of a process that is still running.
+const stillActive = 259
+
+// processAlive returns true if a process with the given PID exists and has
+// not exited yet. A process we are not allowed to query exists as well.
+func processAlive(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return !errors.Is(err, windows.ERROR_INVALID_PARAMETER)
+	}
+
+	defer func() {
+		_ = windows.CloseHandle(h)
+	}()
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+
+	return code == stillActive
+}
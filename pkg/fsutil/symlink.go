@@ -0,0 +1,66 @@
+package fsutil
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+)
+
+// FindEscapingSymlinks returns all symlinks below root whose target
+// resolves to a location outside of root, e.g. to leak a file into the store
+// or out of it. Broken symlinks are not included, see FindBrokenSymlinks.
+// The returned paths are slash separated and relative to root. Nothing is
+// skipped, not even .git.
+func FindEscapingSymlinks(root string) ([]string, error) {
+	escaping, _, err := auditSymlinks(root)
+
+	return escaping, err
+}
+
+// FindBrokenSymlinks returns all symlinks below root whose target does not
+// exist or can not be resolved, e.g. because of a loop. The paths have the
+// same format as in FindEscapingSymlinks.
+func FindBrokenSymlinks(root string) ([]string, error) {
+	_, broken, err := auditSymlinks(root)
+
+	return broken, err
+}
+
+func auditSymlinks(root string) ([]string, []string, error) {
+	escaping := []string{}
+	broken := []string{}
+
+	err := Walk(root, WalkOpts{Ignorer: &Ignorer{}}, func(path string, d fs.DirEntry) error {
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path of %q: %w", path, err)
+		}
+
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			broken = append(broken, ToSlash(rel))
+
+			return nil //nolint:nilerr
+		}
+
+		inside, err := IsSubPath(root, target)
+		if err != nil {
+			return err
+		}
+
+		if !inside {
+			escaping = append(escaping, ToSlash(rel))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return escaping, broken, nil
+}
@@ -0,0 +1,51 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindEscapingSymlinks(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require special privileges on windows")
+	}
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	root := filepath.Join(tempdir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "foo.gpg"), []byte("foo"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "outside"), []byte("outside"), 0o600))
+
+	// inside the store, relative and absolute
+	require.NoError(t, os.Symlink("../foo.gpg", filepath.Join(root, "sub", "in.gpg")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "foo.gpg"), filepath.Join(root, "abs.gpg")))
+	// escaping, directly or by a relative path
+	require.NoError(t, os.Symlink(filepath.Join(tempdir, "outside"), filepath.Join(root, "out.gpg")))
+	require.NoError(t, os.Symlink("../../outside", filepath.Join(root, "sub", "rel.gpg")))
+	// dangling
+	require.NoError(t, os.Symlink("missing.gpg", filepath.Join(root, "dangling.gpg")))
+
+	escaping, err := FindEscapingSymlinks(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"out.gpg", "sub/rel.gpg"}, escaping)
+
+	broken, err := FindBrokenSymlinks(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dangling.gpg"}, broken)
+
+	_, err = FindEscapingSymlinks(filepath.Join(tempdir, "non-existing"))
+	assert.Error(t, err)
+}
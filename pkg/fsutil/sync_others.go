@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package fsutil
+
+import (
+	"fmt"
+	"os"
+)
+
+// SyncDir flushes the directory entries of path to disk. Call it after
+// creating, renaming or removing files in path to make the change durable.
+func SyncDir(path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open dir %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	if err := fh.Sync(); err != nil {
+		return fmt.Errorf("failed to sync dir %q: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build windows
+// +build windows
+
+package fsutil
+
+// SyncDir is a no-op on Windows which does not support syncing directories.
+func SyncDir(path string) error {
+	return nil
+}
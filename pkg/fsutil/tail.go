@@ -0,0 +1,69 @@
+package fsutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// tailBlockSize is the size of the blocks read by Tail.
+const tailBlockSize = 4096
+
+// Tail returns the last lines of the given file. The file is read backwards
+// in blocks, so only about as much as needed is loaded into memory. If the
+// file has fewer lines all of them are returned. Line endings (LF or CRLF)
+// are stripped.
+func Tail(path string, lines int) ([]string, error) {
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	if lines < 1 || fi.Size() < 1 {
+		return []string{}, nil
+	}
+
+	var data []byte
+
+	pos := fi.Size()
+	for pos > 0 {
+		n := min(pos, tailBlockSize)
+		pos -= n
+
+		buf := make([]byte, n)
+		if _, err := fh.ReadAt(buf, pos); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read %q: %w", path, err)
+		}
+
+		data = append(buf, data...)
+
+		// the trailing newline does not start a new line, so we need one
+		// more to be sure the first requested line is complete
+		if bytes.Count(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) >= lines {
+			break
+		}
+	}
+
+	out := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(out) > lines {
+		out = out[len(out)-lines:]
+	}
+
+	for i, l := range out {
+		out[i] = strings.TrimSuffix(l, "\r")
+	}
+
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTail(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	// spans multiple blocks
+	var sb strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		lines   int
+		want    []string
+	}{
+		{
+			name:    "large",
+			content: sb.String(),
+			lines:   3,
+			want:    []string{"line 1997", "line 1998", "line 1999"},
+		},
+		{
+			name:    "large-many",
+			content: sb.String(),
+			lines:   1000,
+			want:    strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")[1000:],
+		},
+		{
+			name:    "no-trailing-newline",
+			content: "foo\nbar\nbaz",
+			lines:   2,
+			want:    []string{"bar", "baz"},
+		},
+		{
+			name:    "crlf",
+			content: "foo\r\nbar\r\nbaz\r\n",
+			lines:   2,
+			want:    []string{"bar", "baz"},
+		},
+		{
+			name:    "short",
+			content: "foo\nbar\n",
+			lines:   10,
+			want:    []string{"foo", "bar"},
+		},
+		{
+			name:    "empty-lines",
+			content: "foo\n\n\n",
+			lines:   2,
+			want:    []string{"", ""},
+		},
+		{
+			name:    "empty",
+			content: "",
+			lines:   10,
+			want:    []string{},
+		},
+	} {
+		fn := filepath.Join(tempdir, tc.name)
+		require.NoError(t, os.WriteFile(fn, []byte(tc.content), 0o600))
+
+		got, err := Tail(fn, tc.lines)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.want, got, tc.name)
+	}
+
+	_, err = Tail(filepath.Join(tempdir, "non-existing"), 1)
+	assert.Error(t, err)
+}
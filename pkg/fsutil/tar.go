@@ -0,0 +1,216 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// TarOpts controls which entries are written by WriteTar.
+type TarOpts struct {
+	// SkipHidden skips all files and directories starting with a dot.
+	// Note that this also skips the .gpg-id files of a store.
+	SkipHidden bool
+	// Match, if set, filters the files written to the archive like
+	// WalkOpts.Match.
+	Match func(string) bool
+}
+
+// WriteTar writes the directories and regular files below root to w as an
+// uncompressed tar stream, preserving their permissions. Entry names are
+// slash separated and relative to root. .git is always skipped, symlinks and
+// other special files are skipped as well. w is not closed.
+func WriteTar(root string, w io.Writer, opts TarOpts) error {
+	tw := tar.NewWriter(w)
+
+	err := Walk(root, WalkOpts{SkipHidden: opts.SkipHidden, SkipGit: true, Match: opts.Match}, func(path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path of %q: %w", path, err)
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		if !d.IsDir() && !d.Type().IsRegular() {
+			debug.Log("not adding %s to archive: not a regular file", path)
+
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("failed to stat %q: %w", path, err)
+		}
+
+		hdr := &tar.Header{
+			Name:    ToSlash(rel),
+			Mode:    int64(fi.Mode().Perm()),
+			ModTime: fi.ModTime(),
+		}
+
+		if d.IsDir() {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+
+			return tw.WriteHeader(hdr) //nolint:wrapcheck
+		}
+
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = fi.Size()
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write header for %q: %w", path, err)
+		}
+
+		return tarCopyFile(tw, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return nil
+}
+
+func tarCopyFile(w io.Writer, path string) error {
+	fh, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	if _, err := io.Copy(w, fh); err != nil {
+		return fmt.Errorf("failed to add %q to archive: %w", path, err)
+	}
+
+	return nil
+}
+
+// ExtractTar extracts the directories and regular files from the tar stream
+// r into root, creating root if necessary. Existing files are overwritten.
+// Entries that would end up outside of root, either by their name or through
+// a symlink inside root, are refused with ErrOutsideRoot. Other entry types,
+// e.g. symlinks, are skipped. Directory permissions are applied after all
+// entries have been extracted, so read-only directories can be restored.
+func ExtractTar(r io.Reader, root string) error {
+	root = CleanPath(root)
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return fmt.Errorf("failed to create %q: %w", root, err)
+	}
+
+	dirModes := map[string]os.FileMode{}
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read from archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeDir && hdr.Typeflag != tar.TypeReg {
+			debug.Log("not extracting %s: unsupported type %c", hdr.Name, hdr.Typeflag)
+
+			continue
+		}
+
+		p, err := ConfinePath(root, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		if p == root {
+			continue
+		}
+
+		if _, err := confined(root, p); err != nil {
+			return err
+		}
+
+		mode := os.FileMode(hdr.Mode).Perm()
+
+		if hdr.Typeflag == tar.TypeDir {
+			if err := os.MkdirAll(p, 0o700); err != nil {
+				return fmt.Errorf("failed to create %q: %w", p, err)
+			}
+
+			dirModes[p] = mode
+
+			continue
+		}
+
+		if err := extractTarFile(tr, p, mode); err != nil {
+			return err
+		}
+	}
+
+	// apply the modes of the deepest directories first, so a restrictive
+	// parent can not prevent changing its children.
+	dirs := make([]string, 0, len(dirModes))
+	for dir := range dirModes {
+		dirs = append(dirs, dir)
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+
+	for _, dir := range dirs {
+		if err := os.Chmod(dir, dirModes[dir]); err != nil {
+			return fmt.Errorf("failed to set mode of %q: %w", dir, err)
+		}
+	}
+
+	return nil
+}
+
+func extractTarFile(r io.Reader, path string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create %q: %w", filepath.Dir(path), err)
+	}
+
+	// never write through a symlink, it could point anywhere
+	if fi, err := os.Lstat(path); err == nil && !fi.Mode().IsRegular() {
+		return fmt.Errorf("can not overwrite %q: not a regular file", path)
+	}
+
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	defer func() {
+		_ = fh.Close()
+	}()
+
+	if _, err := io.Copy(fh, r); err != nil {
+		return fmt.Errorf("failed to extract %q: %w", path, err)
+	}
+
+	if err := fh.Close(); err != nil {
+		return fmt.Errorf("failed to close %q: %w", path, err)
+	}
+
+	// set the mode explicitly, the umask would interfere otherwise
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to set mode of %q: %w", path, err)
+	}
+
+	return nil
+}
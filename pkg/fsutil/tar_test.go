@@ -0,0 +1,148 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	src := filepath.Join(tempdir, "src")
+	files := map[string]os.FileMode{
+		".gpg-id":             0o600,
+		"foo.gpg":             0o600,
+		"sub/bar.gpg":         0o600,
+		"sub/dir/baz.gpg":     0o640,
+		"sub/dir/with space":  0o600,
+		"sub/dir/.public-key": 0o644,
+	}
+
+	for fn, mode := range files {
+		fp := filepath.Join(src, filepath.FromSlash(fn))
+		require.NoError(t, os.MkdirAll(filepath.Dir(fp), 0o700))
+		require.NoError(t, os.WriteFile(fp, []byte(fn), 0o600))
+		require.NoError(t, os.Chmod(fp, mode))
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(src, ".git"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, ".git", "config"), []byte("[core]"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "empty"), 0o750))
+	require.NoError(t, os.Chmod(filepath.Join(src, "empty"), 0o750))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteTar(src, buf, TarOpts{}))
+
+	dst := filepath.Join(tempdir, "dst")
+	require.NoError(t, ExtractTar(bytes.NewReader(buf.Bytes()), dst))
+
+	for fn, mode := range files {
+		fp := filepath.Join(dst, filepath.FromSlash(fn))
+
+		content, err := os.ReadFile(fp)
+		require.NoError(t, err, fn)
+		assert.Equal(t, fn, string(content))
+
+		if runtime.GOOS == "windows" {
+			continue
+		}
+
+		fi, err := os.Stat(fp)
+		require.NoError(t, err)
+		assert.Equal(t, mode, fi.Mode().Perm(), fn)
+	}
+
+	assert.Equal(t, false, Exists(filepath.Join(dst, ".git")))
+	assert.Equal(t, true, IsDir(filepath.Join(dst, "empty")))
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(filepath.Join(dst, "empty"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o750), fi.Mode().Perm())
+	}
+
+	// hidden files can be left out
+	buf.Reset()
+	require.NoError(t, WriteTar(src, buf, TarOpts{SkipHidden: true}))
+
+	hidden := filepath.Join(tempdir, "hidden")
+	require.NoError(t, ExtractTar(buf, hidden))
+	assert.Equal(t, true, IsFile(filepath.Join(hidden, "sub", "bar.gpg")))
+	assert.Equal(t, false, Exists(filepath.Join(hidden, ".gpg-id")))
+	assert.Equal(t, false, Exists(filepath.Join(hidden, "sub", "dir", ".public-key")))
+}
+
+func TestExtractTarTraversal(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	mkTar := func(name string) *bytes.Buffer {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o600,
+			Size:     4,
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte("evil"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+
+		return buf
+	}
+
+	root := filepath.Join(tempdir, "root")
+
+	assert.ErrorIs(t, ExtractTar(mkTar("../evil"), root), ErrOutsideRoot)
+	assert.ErrorIs(t, ExtractTar(mkTar("foo/../../evil"), root), ErrOutsideRoot)
+	assert.ErrorIs(t, ExtractTar(mkTar("/evil"), root), ErrOutsideRoot)
+	assert.Equal(t, false, Exists(filepath.Join(tempdir, "evil")))
+
+	require.NoError(t, ExtractTar(mkTar("foo/../good"), root))
+	assert.Equal(t, true, IsFile(filepath.Join(root, "good")))
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// a symlink inside root must not be used to escape it
+	outside := filepath.Join(tempdir, "outside")
+	require.NoError(t, os.Mkdir(outside, 0o700))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+
+	assert.ErrorIs(t, ExtractTar(mkTar("link/evil"), root), ErrOutsideRoot)
+	assert.ErrorIs(t, ExtractTar(mkTar("link/sub/evil"), root), ErrOutsideRoot)
+	assert.Equal(t, false, Exists(filepath.Join(outside, "evil")))
+	assert.Equal(t, false, Exists(filepath.Join(outside, "sub")))
+
+	// and a symlink to a file is not written through either
+	target := filepath.Join(outside, "target")
+	require.NoError(t, os.WriteFile(target, []byte("keep"), 0o600))
+	require.NoError(t, os.Symlink(target, filepath.Join(root, "file")))
+	assert.Error(t, ExtractTar(mkTar("file"), root))
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "keep", string(content))
+}
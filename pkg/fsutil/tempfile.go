@@ -0,0 +1,69 @@
+package fsutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecureTempFile creates a new temporary file in dir that is only accessible
+// by the current user (0600). The pattern is handled like in os.CreateTemp.
+// For plaintext secrets dir should point to a location the caller controls,
+// ideally a RAM backed filesystem like /dev/shm on Linux. The caller is
+// responsible for shredding the file once it is no longer needed.
+func SecureTempFile(dir, pattern string) (*os.File, error) {
+	fh, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	if err := fh.Chmod(0o600); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(fh.Name())
+
+		return nil, fmt.Errorf("failed to set mode of %q: %w", fh.Name(), err)
+	}
+
+	return fh, nil
+}
+
+// SecureTempDir creates a new temporary directory in dir that is only
+// accessible by the current user (0700). The pattern is handled like in
+// os.MkdirTemp. The caller is responsible for shredding the content of the
+// directory before removing it.
+func SecureTempDir(dir, pattern string) (string, error) {
+	td, err := os.MkdirTemp(dir, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	if err := os.Chmod(td, 0o700); err != nil {
+		_ = os.Remove(td)
+
+		return "", fmt.Errorf("failed to set mode of %q: %w", td, err)
+	}
+
+	return td, nil
+}
+
+// ContentTempName returns a filename derived from the SHA-256 hash of data,
+// so the same content always maps to the same name, e.g. for caching
+// decrypted attachments. ext is appended as is, a missing leading dot is
+// added.
+func ContentTempName(data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	return hex.EncodeToString(sum[:])[:16] + ext
+}
+
+// ContentTempPath returns the path of ContentTempName in dir.
+func ContentTempPath(dir string, data []byte, ext string) string {
+	return filepath.Join(dir, ContentTempName(data, ext))
+}
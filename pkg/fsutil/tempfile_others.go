@@ -0,0 +1,21 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !solaris && !aix
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!solaris,!aix
+
+package fsutil
+
+import (
+	"os"
+)
+
+// SecureTempFileIn creates a new file with a random name in dir and returns
+// it together with its path. Windows and the other platforms handled here
+// have no sticky directories or O_NOFOLLOW, so this is the same as
+// SecureTempFile.
+func SecureTempFileIn(dir string) (*os.File, string, error) {
+	fh, err := SecureTempFile(dir, "gopass-")
+	if err != nil {
+		return nil, "", err
+	}
+
+	return fh, fh.Name(), nil
+}
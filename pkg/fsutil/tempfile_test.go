@@ -0,0 +1,58 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureTempFile(t *testing.T) {
+	t.Parallel()
+
+	td, err := SecureTempDir("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(td)
+	}()
+
+	fh, err := SecureTempFile(td, "secret-*.txt")
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+
+	assert.Equal(t, td, filepath.Dir(fh.Name()))
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	fi, err := os.Stat(td)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), fi.Mode().Perm())
+
+	fi, err = os.Stat(fh.Name())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	_, err = SecureTempFile(filepath.Join(td, "non-existing"), "secret-*")
+	assert.Error(t, err)
+}
+
+func TestContentTempName(t *testing.T) {
+	t.Parallel()
+
+	// sha256("foobar") = c3ab8ff13720e8ad...
+	assert.Equal(t, "c3ab8ff13720e8ad.pdf", ContentTempName([]byte("foobar"), ".pdf"))
+	assert.Equal(t, "c3ab8ff13720e8ad.pdf", ContentTempName([]byte("foobar"), "pdf"))
+	assert.Equal(t, "c3ab8ff13720e8ad.tar.gz", ContentTempName([]byte("foobar"), ".tar.gz"))
+	assert.Equal(t, "c3ab8ff13720e8ad", ContentTempName([]byte("foobar"), ""))
+	assert.Equal(t, ContentTempName([]byte("foobar"), ".txt"), ContentTempName([]byte("foobar"), ".txt"))
+	assert.NotEqual(t, ContentTempName([]byte("foobar"), ".txt"), ContentTempName([]byte("foobaz"), ".txt"))
+
+	dir := filepath.Join("tmp", "cache")
+	assert.Equal(t, filepath.Join(dir, "c3ab8ff13720e8ad.pdf"), ContentTempPath(dir, []byte("foobar"), "pdf"))
+}
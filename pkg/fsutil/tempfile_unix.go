@@ -0,0 +1,56 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || aix
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris aix
+
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// SecureTempFileIn creates a new file with a random name and mode 0600 in
+// dir and returns it opened for reading and writing together with its path.
+// It is safe to use in shared, world-writable directories like /tmp: the
+// file is created with O_EXCL and O_NOFOLLOW, so a name planted by another
+// user, even as a (dangling) symlink, is never opened. The caller is
+// responsible for shredding the file once it is no longer needed.
+func SecureTempFileIn(dir string) (*os.File, string, error) {
+	for i := 0; i < 100; i++ {
+		fn := filepath.Join(dir, "gopass-"+randomSuffix())
+
+		fh, err := createNoFollow(fn)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		return fh, fn, nil
+	}
+
+	return nil, "", fmt.Errorf("failed to create temp file in %q: too many collisions", dir)
+}
+
+// createNoFollow creates path with mode 0600. It fails if path exists in
+// any form, including a symlink.
+func createNoFollow(path string) (*os.File, error) {
+	fh, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL|syscall.O_NOFOLLOW, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %q: %w", path, err)
+	}
+
+	if err := fh.Chmod(0o600); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(path)
+
+		return nil, fmt.Errorf("failed to set mode of %q: %w", path, err)
+	}
+
+	return fh, nil
+}
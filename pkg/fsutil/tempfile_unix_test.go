@@ -0,0 +1,62 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || aix
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris aix
+
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureTempFileIn(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	// make it look like /tmp
+	require.NoError(t, os.Chmod(tempdir, 0o777|os.ModeSticky))
+
+	fh, fn, err := SecureTempFileIn(tempdir)
+	require.NoError(t, err)
+	assert.Equal(t, fh.Name(), fn)
+	assert.Equal(t, tempdir, filepath.Dir(fn))
+
+	_, err = fh.WriteString("plaintext")
+	require.NoError(t, err)
+	require.NoError(t, fh.Close())
+
+	fi, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	other, fn2, err := SecureTempFileIn(tempdir)
+	require.NoError(t, err)
+	assert.NotEqual(t, fn, fn2)
+	_ = other.Close()
+
+	// existing names are rejected
+	_, err = createNoFollow(fn)
+	assert.ErrorIs(t, err, os.ErrExist)
+
+	// and so are planted symlinks, even dangling ones
+	target := filepath.Join(tempdir, "target")
+	link := filepath.Join(tempdir, "planted")
+	require.NoError(t, os.Symlink(target, link))
+
+	_, err = createNoFollow(link)
+	assert.Error(t, err)
+	assert.Equal(t, true, NotExist(target))
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "plaintext", string(buf))
+}
@@ -0,0 +1,159 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// WalkOpts controls which entries are visited by Walk.
+type WalkOpts struct {
+	// SkipHidden skips all files and directories starting with a dot.
+	SkipHidden bool
+	// SkipGit skips .git directories (or files, for worktrees).
+	SkipGit bool
+	// FollowSymlinks descends into symlinked directories and reports the
+	// targets of symlinks instead of the links. Symlink loops are detected
+	// and not followed.
+	FollowSymlinks bool
+	// Match, if set, filters the non-directory entries passed to fn. It
+	// receives the slash separated path relative to the root. Directories
+	// are always visited.
+	Match func(string) bool
+	// Ignorer skips all matching files and directories. If nil the
+	// DefaultIgnorer is used, pass an empty Ignorer to visit everything.
+	Ignorer *Ignorer
+	// OnReadDirError, if set, is called when a directory can not be read.
+	// If it returns nil the directory is skipped and the walk continues,
+	// otherwise the walk stops with the returned error. If nil the walk
+	// stops with the error of ReadDir.
+	OnReadDirError func(path string, err error) error
+}
+
+// Walk walks the file tree rooted at root in lexical order, calling fn for
+// each file or directory that is not skipped by opts, including root itself.
+// Like filepath.WalkDir, fn may return fs.SkipDir to skip a directory (or
+// the remaining entries of the current directory when returned for a file).
+func Walk(root string, opts WalkOpts, fn func(path string, d fs.DirEntry) error) error {
+	fi, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("failed to stat %q: %w", root, err)
+	}
+
+	opts.Ignorer = opts.Ignorer.orDefault()
+
+	w := &walker{
+		root: root,
+		opts: opts,
+		fn:   fn,
+		seen: map[string]bool{},
+	}
+
+	if err := w.walk(root, fs.FileInfoToDirEntry(fi)); err != nil && !errors.Is(err, fs.SkipDir) {
+		return err
+	}
+
+	return nil
+}
+
+type walker struct {
+	root string
+	opts WalkOpts
+	fn   func(path string, d fs.DirEntry) error
+	// seen holds the resolved paths of all directories currently being
+	// walked, to detect symlink loops.
+	seen map[string]bool
+}
+
+func (w *walker) walk(path string, d fs.DirEntry) error {
+	if !d.IsDir() {
+		if w.opts.Match != nil && !w.opts.Match(w.rel(path)) {
+			return nil
+		}
+
+		return w.fn(path, d)
+	}
+
+	if err := w.fn(path, d); err != nil {
+		return err
+	}
+
+	if w.opts.FollowSymlinks {
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %q: %w", path, err)
+		}
+
+		if w.seen[real] {
+			debug.Log("not following symlink loop at %s", path)
+
+			return nil
+		}
+
+		w.seen[real] = true
+		defer delete(w.seen, real)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		err = fmt.Errorf("failed to read dir %q: %w", path, err)
+		if w.opts.OnReadDirError != nil {
+			return w.opts.OnReadDirError(path, err)
+		}
+
+		return err
+	}
+
+	for _, e := range entries {
+		if w.skip(e.Name()) {
+			continue
+		}
+
+		fp := filepath.Join(path, e.Name())
+
+		if w.opts.FollowSymlinks && e.Type()&fs.ModeSymlink != 0 {
+			// broken links are reported as links
+			if fi, err := os.Stat(fp); err == nil {
+				e = fs.FileInfoToDirEntry(fi)
+			}
+		}
+
+		if err := w.walk(fp, e); err != nil {
+			if !errors.Is(err, fs.SkipDir) {
+				return err
+			}
+
+			if !e.IsDir() {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *walker) skip(name string) bool {
+	if w.opts.SkipGit && name == ".git" {
+		return true
+	}
+
+	if w.opts.Ignorer.Ignore(name) {
+		return true
+	}
+
+	return w.opts.SkipHidden && strings.HasPrefix(name, ".")
+}
+
+func (w *walker) rel(path string) string {
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return ToSlash(path)
+	}
+
+	return ToSlash(rel)
+}
@@ -0,0 +1,152 @@
+package fsutil
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func walkFixture(t *testing.T) string {
+	t.Helper()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	for _, dir := range []string{".git/objects", "foo/bar", ".hidden"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempdir, filepath.FromSlash(dir)), 0o700))
+	}
+
+	for _, fn := range []string{
+		".gpg-id",
+		".git/config",
+		".git/objects/abc",
+		".hidden/secret.gpg",
+		"foo/bar/baz.gpg",
+		"foo/zab.gpg",
+		"foo/README.md",
+		"top.gpg",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(tempdir, filepath.FromSlash(fn)), []byte(fn), 0o600))
+	}
+
+	return tempdir
+}
+
+func walkCollect(t *testing.T, root string, opts WalkOpts) []string {
+	t.Helper()
+
+	var out []string
+
+	require.NoError(t, Walk(root, opts, func(path string, d fs.DirEntry) error {
+		rel, err := filepath.Rel(root, path)
+		require.NoError(t, err)
+
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			rel += "/"
+		}
+
+		out = append(out, rel)
+
+		return nil
+	}))
+
+	return out
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	root := walkFixture(t)
+
+	defer func() {
+		_ = os.RemoveAll(root)
+	}()
+
+	assert.Equal(t, []string{
+		"./",
+		".git/", ".git/config", ".git/objects/", ".git/objects/abc",
+		".gpg-id",
+		".hidden/", ".hidden/secret.gpg",
+		"foo/", "foo/README.md", "foo/bar/", "foo/bar/baz.gpg", "foo/zab.gpg",
+		"top.gpg",
+	}, walkCollect(t, root, WalkOpts{}))
+
+	assert.Equal(t, []string{
+		"./",
+		".gpg-id",
+		".hidden/", ".hidden/secret.gpg",
+		"foo/", "foo/README.md", "foo/bar/", "foo/bar/baz.gpg", "foo/zab.gpg",
+		"top.gpg",
+	}, walkCollect(t, root, WalkOpts{SkipGit: true}))
+
+	assert.Equal(t, []string{
+		"./",
+		"foo/", "foo/README.md", "foo/bar/", "foo/bar/baz.gpg", "foo/zab.gpg",
+		"top.gpg",
+	}, walkCollect(t, root, WalkOpts{SkipHidden: true}))
+
+	assert.Equal(t, []string{
+		"./",
+		"foo/", "foo/bar/", "foo/bar/baz.gpg", "foo/zab.gpg",
+		"top.gpg",
+	}, walkCollect(t, root, WalkOpts{
+		SkipHidden: true,
+		Match: func(p string) bool {
+			return strings.HasSuffix(p, ".gpg")
+		},
+	}))
+
+	// fs.SkipDir
+	var out []string
+	require.NoError(t, Walk(root, WalkOpts{SkipHidden: true}, func(path string, d fs.DirEntry) error {
+		if d.Name() == "bar" {
+			return fs.SkipDir
+		}
+
+		out = append(out, d.Name())
+
+		return nil
+	}))
+	assert.Equal(t, []string{filepath.Base(root), "foo", "README.md", "zab.gpg", "top.gpg"}, out)
+
+	assert.Error(t, Walk(filepath.Join(root, "non-existing"), WalkOpts{}, func(string, fs.DirEntry) error { return nil }))
+}
+
+func TestWalkSymlinks(t *testing.T) {
+	t.Parallel()
+
+	root := walkFixture(t)
+
+	defer func() {
+		_ = os.RemoveAll(root)
+	}()
+
+	require.NoError(t, os.Symlink("..", filepath.Join(root, "foo", "bar", "loop")))
+	require.NoError(t, os.Symlink(filepath.Join("bar", "baz.gpg"), filepath.Join(root, "foo", "link.gpg")))
+	require.NoError(t, os.Symlink("non-existing", filepath.Join(root, "broken")))
+
+	opts := WalkOpts{SkipHidden: true}
+	assert.Equal(t, []string{
+		"./",
+		"broken",
+		"foo/", "foo/README.md", "foo/bar/", "foo/bar/baz.gpg", "foo/bar/loop", "foo/link.gpg", "foo/zab.gpg",
+		"top.gpg",
+	}, walkCollect(t, root, opts))
+
+	opts.FollowSymlinks = true
+	assert.Equal(t, []string{
+		"./",
+		"broken",
+		"foo/", "foo/README.md", "foo/bar/", "foo/bar/baz.gpg",
+		// foo/bar/loop points back to foo which is not followed again
+		"foo/bar/loop/",
+		"foo/link.gpg", "foo/zab.gpg",
+		"top.gpg",
+	}, walkCollect(t, root, opts))
+}
@@ -0,0 +1,74 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || solaris || windows
+// +build linux darwin dragonfly freebsd netbsd openbsd solaris windows
+
+package fsutil
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// watchDebounce is the time to wait for more events before reporting a
+// change. Editors and atomic writes often produce several events at once.
+const watchDebounce = 100 * time.Millisecond
+
+// WatchFile calls onChange whenever the file at path is written, replaced,
+// renamed or removed, until ctx is canceled. Bursts of events are reported
+// only once. The parent directory is watched instead of the file itself, so
+// the watch survives the file being replaced by a rename, e.g. by
+// WriteFileAtomic, which changes its inode. WatchFile blocks and returns nil
+// once ctx is canceled.
+func WatchFile(ctx context.Context, path string, onChange func()) error {
+	path = filepath.Clean(path)
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	defer func() {
+		_ = w.Close()
+	}()
+
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to watch %q: %w", path, err)
+	}
+
+	timer := time.NewTimer(watchDebounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+
+			if filepath.Clean(ev.Name) != path || ev.Op == fsnotify.Chmod {
+				continue
+			}
+
+			debug.Log("got event for %s: %s", path, ev)
+			timer.Reset(watchDebounce)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+
+			return fmt.Errorf("failed to watch %q: %w", path, err)
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
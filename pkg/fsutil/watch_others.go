@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !solaris && !windows
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd,!solaris,!windows
+
+package fsutil
+
+import "context"
+
+// WatchFile is not supported on this platform.
+func WatchFile(ctx context.Context, path string, onChange func()) error {
+	return ErrNotSupported
+}
@@ -0,0 +1,99 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows
+// +build linux darwin dragonfly freebsd netbsd openbsd windows
+
+package fsutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFile(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, ".gpg-id")
+	require.NoError(t, os.WriteFile(fn, []byte("foo"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 100)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- WatchFile(ctx, fn, func() {
+			changes <- struct{}{}
+		})
+	}()
+
+	// keep changing the file until the watcher is up and reports it. wait
+	// long enough between the changes so they are not debounced.
+	waitChange := func(change func()) {
+		t.Helper()
+
+		timeout := time.After(5 * time.Second)
+		tick := time.NewTicker(5 * watchDebounce)
+
+		defer tick.Stop()
+
+		for {
+			change()
+
+			select {
+			case <-changes:
+				return
+			case <-tick.C:
+			case <-timeout:
+				t.Fatal("no change reported")
+			}
+		}
+	}
+
+	waitChange(func() {
+		require.NoError(t, os.WriteFile(fn, []byte("bar"), 0o600))
+	})
+
+	// the watch survives the file being replaced
+	waitChange(func() {
+		require.NoError(t, WriteFileAtomic(fn, []byte("baz"), 0o600))
+	})
+
+	waitChange(func() {
+		require.NoError(t, os.WriteFile(fn, []byte("qux"), 0o600))
+	})
+
+	// other files are ignored
+	for len(changes) > 0 {
+		<-changes
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempdir, "other"), []byte("foo"), 0o600))
+
+	select {
+	case <-changes:
+		t.Error("change of other file reported")
+	case <-time.After(3 * watchDebounce):
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not stop")
+	}
+}
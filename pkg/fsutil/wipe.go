@@ -0,0 +1,22 @@
+package fsutil
+
+import "runtime"
+
+// Wipe overwrites b with zeros, e.g. to remove a decrypted secret from
+// memory once it's no longer needed:
+//
+//	buf, err := decrypt(...)
+//	defer fsutil.Wipe(buf)
+//
+// Copies of b, e.g. made by append or by converting it to a string, are not
+// affected. There is deliberately no WipeString: Go strings are immutable,
+// so they can not be wiped. Keep secrets in byte slices instead.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+
+	// make sure the writes are not optimized away since b is not used
+	// afterwards
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,22 @@
+package fsutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWipe(t *testing.T) {
+	t.Parallel()
+
+	buf := []byte("secret")
+	Wipe(buf)
+	assert.Equal(t, make([]byte, 6), buf)
+
+	// only the slice is wiped, not the rest of the array
+	buf = []byte("foobar")
+	Wipe(buf[:3])
+	assert.Equal(t, []byte{0, 0, 0, 'b', 'a', 'r'}, buf)
+
+	Wipe(nil)
+}
@@ -0,0 +1,235 @@
+package fsutil
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// WriteFileAtomic writes data to a temporary file in the same directory as
+// path and renames it over path afterwards. Other processes will either
+// see the old or the new content but never a partially written file.
+// The temporary file is created with mode right away.
+func WriteFileAtomic(path string, data []byte, mode os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	fh, err := createTemp(dir, "."+filepath.Base(path)+".tmp-", mode)
+	if err != nil {
+		return err
+	}
+
+	tmp := fh.Name()
+
+	if err := writeSync(fh, data); err != nil {
+		_ = fh.Close()
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to write %q: %w", tmp, err)
+	}
+
+	if err := fh.Close(); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to close %q: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to rename %q to %q: %w", tmp, path, err)
+	}
+
+	return SyncDir(dir)
+}
+
+// WriteFileIfChanged is like WriteFileAtomic but leaves path alone if it
+// already has exactly the content data, e.g. to avoid spurious changes in a
+// git backed store. If only the mode differs it is fixed in place. changed
+// is true if the content or the mode was updated. Mode differences are
+// ignored on Windows, where the mode bits do not reflect the actual ACLs.
+func WriteFileIfChanged(path string, data []byte, mode os.FileMode) (bool, error) {
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, WriteFileAtomic(path, data, mode)
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %q: %w", path, err)
+	}
+
+	if !fi.Mode().IsRegular() {
+		return false, fmt.Errorf("not a regular file: %q", path)
+	}
+
+	if fi.Size() == int64(len(data)) {
+		old, err := os.ReadFile(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %q: %w", path, err)
+		}
+
+		if bytes.Equal(old, data) {
+			if runtime.GOOS == "windows" || fi.Mode().Perm() == mode.Perm() {
+				return false, nil
+			}
+
+			return true, FixPerms(path, mode)
+		}
+	}
+
+	return true, WriteFileAtomic(path, data, mode)
+}
+
+// backupShredRounds is the number of passes used to shred the backup of
+// WriteFileAtomicBackup.
+const backupShredRounds = 8
+
+// WriteFileAtomicBackup is like WriteFileAtomic but moves an existing file
+// at path to path+".bak" first. The backup is only shredded once the new
+// content has been written and synced, if that fails it is moved back over
+// path. Note that path does not exist while it's being written. If the
+// backup file exists already it is not touched and an error is returned,
+// it might be the only copy left from an earlier failure.
+func WriteFileAtomicBackup(path string, data []byte, mode os.FileMode) error {
+	return writeFileAtomicBackup(path, data, mode, WriteFileAtomic)
+}
+
+func writeFileAtomicBackup(path string, data []byte, mode os.FileMode, write func(string, []byte, os.FileMode) error) error {
+	bak := path + ".bak"
+
+	if !NotExist(bak) {
+		return fmt.Errorf("backup %q exists already", bak)
+	}
+
+	if err := os.Rename(path, bak); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to back up %q: %w", path, err)
+		}
+
+		// nothing to back up
+		return write(path, data, mode)
+	}
+
+	if err := write(path, data, mode); err != nil {
+		if rerr := os.Rename(bak, path); rerr != nil {
+			return fmt.Errorf("failed to write %q: %w, failed to restore backup %q: %s", path, err, bak, rerr)
+		}
+
+		return err
+	}
+
+	if err := Shred(bak, backupShredRounds); err != nil {
+		return fmt.Errorf("failed to remove backup %q: %w", bak, err)
+	}
+
+	return nil
+}
+
+// appendAtomicSize is the largest write that is guaranteed to be atomic,
+// at least for pipes (PIPE_BUF). Most local filesystems behave the same.
+const appendAtomicSize = 4096
+
+// AppendFileAtomic appends data to the file at path with a single write and
+// syncs it to disk. If the file does not exist yet it is created with
+// exactly mode, regardless of the umask. Appends of up to 4 KiB will never
+// be interleaved with concurrent appends or be only partially visible after
+// a crash. For larger payloads this is not guaranteed, use WriteFileAtomic
+// with the full content instead if that matters.
+func AppendFileAtomic(path string, data []byte, mode os.FileMode) error {
+	if len(data) > appendAtomicSize {
+		debug.Log("appending %d bytes to %s, this might not be atomic", len(data), path)
+	}
+
+	fh, err := openAppend(path, mode)
+	if err != nil {
+		return err
+	}
+
+	if err := writeSync(fh, data); err != nil {
+		_ = fh.Close()
+
+		return fmt.Errorf("failed to append to %q: %w", path, err)
+	}
+
+	if err := fh.Close(); err != nil {
+		return fmt.Errorf("failed to close %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// openAppend opens path for appending. If it does not exist it is created
+// with mode.
+func openAppend(path string, mode os.FileMode) (*os.File, error) {
+	fh, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, mode)
+	if err == nil {
+		return fh, nil
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open %q: %w", path, err)
+	}
+
+	fh, err = CreateExclusive(path, mode)
+	if errors.Is(err, fs.ErrExist) {
+		// someone else was faster
+		return openAppend(path, mode)
+	}
+
+	return fh, err
+}
+
+func writeSync(fh *os.File, data []byte) error {
+	if _, err := fh.Write(data); err != nil {
+		return fmt.Errorf("failed to write: %w", err)
+	}
+
+	if err := fh.Sync(); err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+
+	return nil
+}
+
+// createTemp creates a new file with a random name starting with prefix in
+// dir. Unlike os.CreateTemp the file is created with mode right away and the
+// umask is not applied.
+func createTemp(dir, prefix string, mode os.FileMode) (*os.File, error) {
+	for i := 0; i < 100; i++ {
+		fn := filepath.Join(dir, prefix+randomSuffix())
+
+		fh, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp file in %q: %w", dir, err)
+		}
+
+		if err := fh.Chmod(mode); err != nil {
+			_ = fh.Close()
+			_ = os.Remove(fn)
+
+			return nil, fmt.Errorf("failed to set mode of %q: %w", fn, err)
+		}
+
+		return fh, nil
+	}
+
+	return nil, fmt.Errorf("failed to create temp file in %q: too many collisions", dir)
+}
+
+func randomSuffix() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
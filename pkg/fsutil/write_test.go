@@ -0,0 +1,175 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret.gpg")
+	require.NoError(t, WriteFileAtomic(fn, []byte("foo"), 0o600))
+	require.NoError(t, WriteFileAtomic(fn, []byte("bar"), 0o640))
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+
+	fi, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), fi.Mode().Perm())
+
+	// no temp files are left behind
+	entries, err := os.ReadDir(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.Error(t, WriteFileAtomic(filepath.Join(tempdir, "non-existing", "foo"), []byte("foo"), 0o600))
+	assert.Error(t, WriteFileAtomic(tempdir, []byte("foo"), 0o600))
+
+	entries, err = os.ReadDir(tempdir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFileAtomicBackup(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret.gpg")
+	require.NoError(t, WriteFileAtomicBackup(fn, []byte("foo"), 0o600))
+	require.NoError(t, WriteFileAtomicBackup(fn, []byte("bar"), 0o600))
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+	assert.Equal(t, false, Exists(fn+".bak"))
+
+	// a failed write restores the previous content
+	err = writeFileAtomicBackup(fn, []byte("baz"), 0o600, func(path string, data []byte, mode os.FileMode) error {
+		assert.Equal(t, true, IsFile(path+".bak"))
+		require.NoError(t, os.WriteFile(path, data[:1], mode))
+
+		return fmt.Errorf("disk full")
+	})
+	assert.Error(t, err)
+
+	buf, err = os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+	assert.Equal(t, false, Exists(fn+".bak"))
+
+	// an existing backup is never replaced
+	require.NoError(t, os.WriteFile(fn+".bak", []byte("old"), 0o600))
+	assert.Error(t, WriteFileAtomicBackup(fn, []byte("baz"), 0o600))
+
+	buf, err = os.ReadFile(fn + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(buf))
+}
+
+func TestAppendFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "audit.log")
+	for _, line := range []string{"foo\n", "bar\n", "baz\n"} {
+		require.NoError(t, AppendFileAtomic(fn, []byte(line), 0o600))
+	}
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\nbaz\n", string(buf))
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(fn)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+	}
+
+	assert.Error(t, AppendFileAtomic(filepath.Join(tempdir, "non-existing", "audit.log"), []byte("foo"), 0o600))
+}
+
+func TestWriteFileIfChanged(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret.gpg")
+
+	changed, err := WriteFileIfChanged(fn, []byte("foo"), 0o600)
+	require.NoError(t, err)
+	assert.Equal(t, true, changed)
+
+	before, err := os.Stat(fn)
+	require.NoError(t, err)
+
+	// same content, the file must not be replaced
+	changed, err = WriteFileIfChanged(fn, []byte("foo"), 0o600)
+	require.NoError(t, err)
+	assert.Equal(t, false, changed)
+
+	after, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, true, os.SameFile(before, after))
+
+	// new content
+	changed, err = WriteFileIfChanged(fn, []byte("bar"), 0o600)
+	require.NoError(t, err)
+	assert.Equal(t, true, changed)
+
+	buf, err := os.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(buf))
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// same content but different mode
+	before, err = os.Stat(fn)
+	require.NoError(t, err)
+
+	changed, err = WriteFileIfChanged(fn, []byte("bar"), 0o640)
+	require.NoError(t, err)
+	assert.Equal(t, true, changed)
+
+	after, err = os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(t, true, os.SameFile(before, after))
+	assert.Equal(t, os.FileMode(0o640), after.Mode().Perm())
+
+	_, err = WriteFileIfChanged(tempdir, []byte("foo"), 0o600)
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package fsutil
+
+import (
+	"errors"
+
+	"github.com/gopasspw/gopass/pkg/debug"
+)
+
+// clearXattrs removes all extended attributes from the file at path. It's
+// used before removing a shredded file, since they might contain metadata
+// about the secret. Failures are only logged, some attributes can not be
+// removed by regular users.
+func clearXattrs(path string) {
+	names, err := ListXattr(path)
+	if err != nil {
+		if !errors.Is(err, ErrNotSupported) {
+			debug.Log("failed to list xattrs of %s: %s", path, err)
+		}
+
+		return
+	}
+
+	for _, name := range names {
+		if err := RemoveXattr(path, name); err != nil {
+			debug.Log("failed to remove xattr %s of %s: %s", name, path, err)
+		}
+	}
+}
+
+// splitExtattrList parses the output of extattr_list_file(2) on FreeBSD.
+// Unlike listxattr(2) on Linux the names are not NUL terminated but each
+// preceded by a single length byte, and they lack the namespace, so prefix
+// is added to each of them. A truncated last entry is dropped.
+func splitExtattrList(buf []byte, prefix string) []string {
+	names := []string{}
+
+	for len(buf) > 0 {
+		l := int(buf[0])
+		if l+1 > len(buf) {
+			break
+		}
+
+		names = append(names, prefix+string(buf[1:l+1]))
+		buf = buf[l+1:]
+	}
+
+	return names
+}
@@ -0,0 +1,61 @@
+//go:build linux
+// +build linux
+
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXattr(t *testing.T) {
+	t.Parallel()
+
+	tempdir, err := os.MkdirTemp("", "gopass-")
+	require.NoError(t, err)
+
+	defer func() {
+		_ = os.RemoveAll(tempdir)
+	}()
+
+	fn := filepath.Join(tempdir, "secret.gpg")
+	require.NoError(t, os.WriteFile(fn, []byte("secret"), 0o600))
+
+	name := "user.gopass.rotated"
+
+	err = SetXattr(fn, name, []byte("2022-05-01"))
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip("xattrs are not supported by the filesystem")
+	}
+
+	require.NoError(t, err)
+
+	value, err := GetXattr(fn, name)
+	require.NoError(t, err)
+	assert.Equal(t, "2022-05-01", string(value))
+
+	names, err := ListXattr(fn)
+	require.NoError(t, err)
+	assert.Contains(t, names, name)
+
+	_, err = GetXattr(fn, "user.gopass.missing")
+	assert.Error(t, err)
+
+	// shred removes the attributes before unlinking the file. look at them
+	// through another link to the same inode.
+	peek := filepath.Join(tempdir, "peek")
+	require.NoError(t, os.Link(fn, peek))
+	require.NoError(t, Shred(fn, 1))
+
+	names, err = ListXattr(peek)
+	require.NoError(t, err)
+	assert.NotContains(t, names, name)
+
+	_, err = GetXattr(peek, name)
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+//go:build freebsd
+// +build freebsd
+
+package fsutil
+
+import (
+	"errors"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrNamespaces maps the FreeBSD extattr namespaces to the prefixes
+// understood by the Linux style xattr functions of x/sys/unix.
+var xattrNamespaces = []struct {
+	id     int
+	prefix string
+}{
+	{id: unix.EXTATTR_NAMESPACE_USER, prefix: "user."},
+	{id: unix.EXTATTR_NAMESPACE_SYSTEM, prefix: "system."},
+}
+
+// listXattr lists the attributes of each namespace separately, see
+// splitExtattrList.
+func listXattr(path string) ([]string, error) {
+	names := []string{}
+
+	for _, ns := range xattrNamespaces {
+		buf, err := extattrList(path, ns.id)
+		if errors.Is(err, unix.EPERM) && ns.id != unix.EXTATTR_NAMESPACE_USER {
+			// only root may list system attributes
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, splitExtattrList(buf, ns.prefix)...)
+	}
+
+	return names, nil
+}
+
+func extattrList(path string, ns int) ([]byte, error) {
+	sz, err := unix.ExtattrListFile(path, ns, 0, 0)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	if sz == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, sz)
+
+	n, err := unix.ExtattrListFile(path, ns, uintptr(unsafe.Pointer(&buf[0])), len(buf))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return buf[:n], nil
+}
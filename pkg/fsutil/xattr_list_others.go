@@ -0,0 +1,43 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package fsutil
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// listXattr returns the NUL separated list of listxattr(2) as a slice.
+func listXattr(path string) ([]string, error) {
+	for {
+		sz, err := unix.Listxattr(path, nil)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		buf := make([]byte, sz)
+
+		n, err := unix.Listxattr(path, buf)
+		if errors.Is(err, unix.ERANGE) {
+			// the list grew in the meantime
+			continue
+		}
+
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		names := []string{}
+
+		for _, name := range bytes.Split(buf[:n], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+
+		return names, nil
+	}
+}
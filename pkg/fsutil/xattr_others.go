@@ -0,0 +1,24 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package fsutil
+
+// SetXattr is not supported on this platform.
+func SetXattr(path, name string, value []byte) error {
+	return ErrNotSupported
+}
+
+// GetXattr is not supported on this platform.
+func GetXattr(path, name string) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+// ListXattr is not supported on this platform.
+func ListXattr(path string) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+// RemoveXattr is not supported on this platform.
+func RemoveXattr(path, name string) error {
+	return ErrNotSupported
+}
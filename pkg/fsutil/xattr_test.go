@@ -0,0 +1,24 @@
+package fsutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitExtattrList(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		in   []byte
+		want []string
+	}{
+		{name: "empty", in: nil, want: []string{}},
+		{name: "single", in: []byte("\x03foo"), want: []string{"user.foo"}},
+		{name: "multiple", in: []byte("\x03foo\x0bgopass.sync"), want: []string{"user.foo", "user.gopass.sync"}},
+		{name: "truncated", in: []byte("\x03foo\x05ba"), want: []string{"user.foo"}},
+	} {
+		assert.Equal(t, tc.want, splitExtattrList(tc.in, "user."), tc.name)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// SetXattr sets the extended attribute name of the file at path to value.
+// On Linux and FreeBSD name must include the namespace, e.g.
+// "user.gopass.rotated". It returns ErrNotSupported if the filesystem does
+// not support extended attributes.
+func SetXattr(path, name string, value []byte) error {
+	if err := unix.Setxattr(path, name, value, 0); err != nil {
+		return xattrError("set", path, name, err)
+	}
+
+	return nil
+}
+
+// GetXattr returns the value of the extended attribute name of the file at
+// path.
+func GetXattr(path, name string) ([]byte, error) {
+	for {
+		sz, err := unix.Getxattr(path, name, nil)
+		if err != nil {
+			return nil, xattrError("get", path, name, err)
+		}
+
+		buf := make([]byte, sz)
+
+		n, err := unix.Getxattr(path, name, buf)
+		if errors.Is(err, unix.ERANGE) {
+			// the value has grown in the meantime
+			continue
+		}
+
+		if err != nil {
+			return nil, xattrError("get", path, name, err)
+		}
+
+		return buf[:n], nil
+	}
+}
+
+// ListXattr returns the names of all extended attributes of the file at
+// path.
+func ListXattr(path string) ([]string, error) {
+	names, err := listXattr(path)
+	if err != nil {
+		return nil, xattrError("list", path, "", err)
+	}
+
+	return names, nil
+}
+
+// RemoveXattr removes the extended attribute name from the file at path.
+func RemoveXattr(path, name string) error {
+	if err := unix.Removexattr(path, name); err != nil {
+		return xattrError("remove", path, name, err)
+	}
+
+	return nil
+}
+
+func xattrError(op, path, name string, err error) error {
+	if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) {
+		return fmt.Errorf("can not %s xattrs of %q: %s: %w", op, path, err, ErrNotSupported)
+	}
+
+	if name == "" {
+		return fmt.Errorf("failed to %s xattrs of %q: %w", op, path, err)
+	}
+
+	return fmt.Errorf("failed to %s xattr %q of %q: %w", op, name, path, err)
+}